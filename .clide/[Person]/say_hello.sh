@@ -1,3 +1,7 @@
 #!/usr/bin/env sh
+# clide: description=Say hello to someone
+# clide: alias=hi
+# clide: help=Greets the person given by the [Person] parameter.
+# clide: help=Try `clide hi -p=Alice`.
 
 echo "Hello $person!"
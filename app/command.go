@@ -0,0 +1,261 @@
+package model
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/TeddyRandby/clide/node"
+)
+
+// shellQuote quotes s for a POSIX shell, unless it is safe to use as is.
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r == '-' || r == '_' || r == '.' || r == '/' || r == ',' || r == ':' || r == '=' || r == '@' ||
+			(r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9'))
+	}) < 0 {
+		return s
+	}
+
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// splitMulti splits the value of a multi-select argument on commas. A comma
+// escaped with a backslash is kept, and any other backslash is left as is.
+func splitMulti(s string) []string {
+	values := make([]string, 0)
+
+	var value strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		switch {
+		case strings.HasPrefix(s[i:], `\,`):
+			value.WriteByte(',')
+			i++
+		case s[i] == ',':
+			values = append(values, value.String())
+			value.Reset()
+		default:
+			value.WriteByte(s[i])
+		}
+	}
+
+	return append(values, value.String())
+}
+
+// escapeMulti escapes the commas in a single value of a multi-select
+// argument, so that splitMulti gives it back whole.
+func escapeMulti(s string) string {
+	return strings.ReplaceAll(s, ",", `\,`)
+}
+
+// splitArgs splits s into words like a POSIX shell, honouring single and
+// double quotes and backslash escapes.
+func splitArgs(s string) ([]string, error) {
+	words := make([]string, 0)
+
+	var word strings.Builder
+	var quote rune
+
+	inWord, escaped := false, false
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inWord = true, true
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			word.WriteRune(r)
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("Unterminated %c quote", quote)
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+
+	return words, nil
+}
+
+// shortestStep is the shortest word which selects n when resolved from
+// from, out of its shortcut, its aliases and the prefixes of its name. It is
+// empty when no word does, as n is shadowed by a closer tree.
+func shortestStep(n node.CommandNode, from *node.CommandNode) string {
+	candidates := make([]string, 0, len(n.Name)+len(n.Meta.Aliases)+1)
+
+	if n.Shortcut != "" {
+		candidates = append(candidates, n.Shortcut)
+	}
+
+	candidates = append(candidates, n.Meta.Aliases...)
+
+	for i := 1; i <= len(n.Name); i++ {
+		candidates = append(candidates, n.Name[:i])
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return len(candidates[i]) < len(candidates[j])
+	})
+
+	for _, candidate := range candidates {
+		if matches := from.Resolve(candidate); len(matches) == 1 && matches[0].Path == n.Path {
+			return candidate
+		}
+	}
+
+	return ""
+}
+
+// steps are the shortest words which select the command from start, where
+// the command line is resolved from. The first word may select a node in any
+// of the trees start falls back to, and the trees of other subprojects can't
+// be selected at all.
+func steps(n *node.CommandNode, start *node.CommandNode) ([]string, bool) {
+	chain := make([]*node.CommandNode, 0)
+
+	for ; n != nil && n.Parent != nil; n = n.Parent {
+		chain = append([]*node.CommandNode{n}, chain...)
+	}
+
+	reachable := []string{start.Path}
+
+	for t := start; t.Parent != nil && t.Path == t.Root; t = t.Parent {
+		reachable = append(reachable, t.Parent.Path)
+	}
+
+	// Start from the deepest node whose parent the first word can reach.
+	first := 0
+
+	for i, n := range chain {
+		if slices.Contains(reachable, n.Parent.Path) {
+			first = i
+		}
+	}
+
+	words := make([]string, 0, len(chain))
+	from := start
+
+	for _, n := range chain[first:] {
+		if n.Path == n.Root && !slices.Contains(reachable, n.Path) {
+			return nil, false
+		}
+
+		word := shortestStep(*n, from)
+
+		if word == "" {
+			return nil, false
+		}
+
+		words = append(words, word)
+		from = n
+	}
+
+	return words, true
+}
+
+// commandLine is the shortest invocation of clide which runs the same command
+// with the same parameters, without opening the menus. When the command is
+// shadowed by a closer tree, or belongs to another subproject, the line
+// changes to the directory of its tree first.
+func (m Clide) commandLine() string {
+	words := []string{"clide"}
+
+	for _, param := range m.params {
+		flag := param.Shortcut
+
+		if flag == "" {
+			flag = param.Name
+		}
+
+		for _, value := range param.Value {
+			if param.Multi {
+				value = escapeMulti(value)
+			}
+
+			words = append(words, fmt.Sprintf("-%s=%s", flag, shellQuote(value)))
+		}
+	}
+
+	route, ok := steps(m.node, m.start())
+
+	if !ok {
+		tree := m.root.Tree(m.node.Root)
+
+		if tree == nil {
+			tree = m.root
+		}
+
+		route, _ = steps(m.node, tree)
+		words = append([]string{"cd", shellQuote(filepath.Dir(tree.Path)), "&&"}, words...)
+	}
+
+	words = append(words, route...)
+
+	if len(m.extra) > 0 {
+		words = append(words, ClideArgsSeparator)
+
+		for _, arg := range m.extra {
+			words = append(words, shellQuote(arg))
+		}
+	}
+
+	return strings.Join(words, " ")
+}
+
+// Last prints the command line of the nth most recent command in history.
+func (m Clide) Last(n int) error {
+	entry, err := m.entry(n)
+
+	if err != nil {
+		return err
+	}
+
+	// The command is found from where it ran, but printed for here.
+	if wd, err := os.Getwd(); err == nil {
+		if c := m.from(entry.Cwd); c.Ok() {
+			m = c
+		}
+
+		os.Chdir(wd)
+	}
+
+	cmd := m.find(entry.Steps)
+
+	if cmd == nil || cmd.Type != node.NodeTypeCommand {
+		return fmt.Errorf("Command '%s' no longer exists", strings.Join(entry.Steps, " "))
+	}
+
+	m.node = cmd
+	m.params = cmd.Parameters()
+
+	for i, param := range m.params {
+		m.params[i].Value = entry.Params[param.Name]
+	}
+
+	m.extra = entry.Args
+
+	fmt.Println(m.commandLine())
+
+	return nil
+}
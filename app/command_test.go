@@ -0,0 +1,100 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"deploy", "deploy"},
+		{"-p=a,b", "-p=a,b"},
+		{"./dir/file.txt", "./dir/file.txt"},
+		{"user@host:22", "user@host:22"},
+		{"", "''"},
+		{"two words", "'two words'"},
+		{"it's", `'it'\''s'`},
+		{"$(touch pwned)", "'$(touch pwned)'"},
+		{"a;b", "'a;b'"},
+		{`back\slash`, `'back\slash'`},
+	}
+
+	for _, test := range tests {
+		if got := shellQuote(test.in); got != test.want {
+			t.Errorf("shellQuote(%q) = %s, want %s", test.in, got, test.want)
+		}
+	}
+}
+
+func TestSplitMulti(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"Alice", []string{"Alice"}},
+		{"Alice,Bob", []string{"Alice", "Bob"}},
+		{"Alice,", []string{"Alice", ""}},
+		{`Smith\, Alice,Bob`, []string{"Smith, Alice", "Bob"}},
+		{`C:\dir,D:\dir`, []string{`C:\dir`, `D:\dir`}},
+		{`a\\,b`, []string{`a\,b`}},
+		{`trailing\`, []string{`trailing\`}},
+	}
+
+	for _, test := range tests {
+		if got := splitMulti(test.in); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("splitMulti(%q) = %q, want %q", test.in, got, test.want)
+		}
+	}
+
+	for _, value := range []string{"Smith, Alice", `C:\dir`, `a\,b`, `trailing\`, ",,"} {
+		if got := splitMulti(escapeMulti(value)); !reflect.DeepEqual(got, []string{value}) {
+			t.Errorf("splitMulti(escapeMulti(%q)) = %q", value, got)
+		}
+	}
+}
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"", []string{}},
+		{"  a \t b\nc  ", []string{"a", "b", "c"}},
+		{`'two words' "and more"`, []string{"two words", "and more"}},
+		{`it\'s`, []string{"it's"}},
+		{`'a\b'`, []string{`a\b`}},
+		{`"say \"hi\""`, []string{`say "hi"`}},
+		{`pre'fix'ed`, []string{"prefixed"}},
+		{`'' ""`, []string{"", ""}},
+		{`a\ b`, []string{"a b"}},
+	}
+
+	for _, test := range tests {
+		got, err := splitArgs(test.in)
+
+		if err != nil {
+			t.Errorf("splitArgs(%q): %s", test.in, err)
+		} else if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("splitArgs(%q) = %q, want %q", test.in, got, test.want)
+		}
+	}
+
+	for _, in := range []string{`'open`, `"open`, `a 'b" c`} {
+		if _, err := splitArgs(in); err == nil {
+			t.Errorf("splitArgs(%q) succeeded, want an error", in)
+		}
+	}
+}
+
+func TestSplitArgsUndoesShellQuote(t *testing.T) {
+	for _, word := range []string{"plain", "two words", "it's", `a\b`, `"quoted"`, ""} {
+		got, err := splitArgs(shellQuote(word))
+
+		if err != nil || !reflect.DeepEqual(got, []string{word}) {
+			t.Errorf("splitArgs(shellQuote(%q)) = %q, %v", word, got, err)
+		}
+	}
+}
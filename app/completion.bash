@@ -0,0 +1,115 @@
+# bash completion for clide
+#
+# Generated by `clide @completion bash`. To load it, add this to ~/.bashrc:
+#
+#   eval "$(clide @completion bash)"
+
+{{template "tree" .}}
+
+__clide_pwd={{quote .Dir}}
+
+__clide_load() {
+	[[ "$__clide_pwd" == "$PWD" ]] && return
+	__clide_pwd="$PWD"
+	__clide_children() { :; }
+	__clide_flags() { :; }
+	eval "$(clide @completion bash tree 2>/dev/null)"
+}
+
+# __clide_step follows the word $2 from the node $1, the way clide does: an
+# exact name wins over a shortcut, which wins over a prefix. Words which match
+# several children at once lead nowhere.
+__clide_step() {
+	local entry tier match
+	for tier in name shortcut prefix; do
+		match=""
+		for entry in $(__clide_children "$1"); do
+			case "$tier" in
+			name) [[ "${entry%%:*}" == "$2" ]] ;;
+			shortcut) [[ -n "${entry#*:}" && "${entry#*:}" == "$2" ]] ;;
+			prefix) [[ "${entry%%:*}" == "$2"* ]] ;;
+			esac || continue
+			[[ -n "$match" ]] && return
+			match="$1${entry%%:*}/"
+		done
+		if [[ -n "$match" ]]; then
+			echo "$match"
+			return
+		fi
+	done
+}
+
+_clide() {
+	local line cur key word entry flag value
+	local -a words
+
+	__clide_load
+
+	line="${COMP_LINE:0:COMP_POINT}"
+	read -ra words <<<"$line"
+
+	cur=""
+	if [[ "$line" != *[[:space:]] ]]; then
+		cur="${words[${#words[@]}-1]}"
+		words=("${words[@]:0:${#words[@]}-1}")
+	fi
+
+	COMPREPLY=()
+
+	if [[ ${#words[@]} -le 1 && "$cur" == @* ]]; then
+		for entry in {{join .Builtins " "}}; do
+			[[ "$entry" == "$cur"* ]] && COMPREPLY+=("$entry")
+		done
+		return
+	fi
+
+	key="/"
+	for word in "${words[@]:1}"; do
+		[[ "$word" == -* ]] && continue
+		[[ "$word" == @* ]] && return
+		key="$(__clide_step "$key" "$word")"
+		[[ -z "$key" ]] && return
+	done
+
+	if [[ "$cur" == -*=* ]]; then
+		flag="${cur%%=*}"
+		while IFS= read -r value; do
+			[[ "$flag=$value" == "$cur"* ]] || continue
+			if [[ "$COMP_WORDBREAKS" == *=* ]]; then
+				COMPREPLY+=("$(printf '%q' "$value")")
+			else
+				COMPREPLY+=("$(printf '%q' "$flag=$value")")
+			fi
+		done < <(clide @completion options "${flag#-}" "${words[@]:1}" 2>/dev/null)
+	elif [[ "$cur" == -* ]]; then
+		for entry in $(__clide_flags "$key"); do
+			[[ "$entry" == "$cur"* ]] && COMPREPLY+=("$entry")
+		done
+		compopt -o nospace 2>/dev/null
+	else
+		for entry in $(__clide_children "$key"); do
+			[[ "${entry%%:*}" == "$cur"* ]] && COMPREPLY+=("${entry%%:*}")
+			[[ -n "$cur" && -n "${entry#*:}" && "${entry#*:}" == "$cur"* ]] && COMPREPLY+=("${entry#*:}")
+		done
+	fi
+}
+
+complete -F _clide clide
+
+{{define "tree" -}}
+__clide_children() {
+	case "$1" in
+{{- range .Nodes}}{{if .Children}}
+	{{quote .Key}}) echo {{quote (join .Children " ")}} ;;
+{{- end}}{{end}}
+	esac
+}
+
+__clide_flags() {
+	case "$1" in
+{{- range .Nodes}}{{if .Flags}}
+	{{quote .Key}}) echo {{quote (join .Flags " ")}} ;;
+{{- end}}{{end}}
+	esac
+}
+{{- end}}
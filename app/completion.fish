@@ -0,0 +1,109 @@
+# fish completion for clide
+#
+# Generated by `clide @completion fish`. To load it, run:
+#
+#   clide @completion fish > ~/.config/fish/completions/clide.fish
+
+{{template "tree" .}}
+
+set -g __clide_pwd {{quote .Dir}}
+
+function __clide_load
+    test "$__clide_pwd" = "$PWD"; and return
+    set -g __clide_pwd $PWD
+    function __clide_children; end
+    function __clide_flags; end
+    clide @completion fish tree 2>/dev/null | source
+end
+
+# __clide_step follows a word from a node, the way clide does: an exact name
+# wins over a shortcut, which wins over a prefix. Words which match several
+# children at once lead nowhere.
+function __clide_step
+    set -l match
+    for tier in name shortcut prefix
+        set match
+        for entry in (__clide_children $argv[1])
+            set -l parts (string split -m1 : -- $entry)
+            switch $tier
+                case name
+                    test "$parts[1]" = "$argv[2]"; or continue
+                case shortcut
+                    test -n "$parts[2]"; and test "$parts[2]" = "$argv[2]"; or continue
+                case prefix
+                    string match -q -- "$argv[2]*" $parts[1]; or continue
+            end
+            test -n "$match"; and return
+            set match $argv[1]$parts[1]/
+        end
+        if test -n "$match"
+            echo $match
+            return
+        end
+    end
+end
+
+function __clide_complete
+    __clide_load
+
+    set -l words (commandline -opc)
+    set -l cur (commandline -ct)
+    set -e words[1]
+
+    if test (count $words) -eq 0; and string match -q -- '@*' $cur
+        printf '%s\n' {{join .Builtins " "}}
+        return
+    end
+
+    set -l key /
+    for word in $words
+        switch $word
+            case '-*'
+                continue
+            case '@*'
+                return
+        end
+        set key (__clide_step $key $word)
+        test -z "$key"; and return
+    end
+
+    switch $cur
+        case '-*=*'
+            set -l flag (string split -m1 = -- $cur)[1]
+            for value in (clide @completion options (string sub -s 2 -- $flag) $words 2>/dev/null)
+                echo $flag=$value
+            end
+        case '-*'
+            __clide_flags $key
+        case '*'
+            for entry in (__clide_children $key)
+                set -l parts (string split -m1 : -- $entry)
+                echo $parts[1]
+                if test -n "$cur"; and test -n "$parts[2]"
+                    printf '%s\t%s\n' $parts[2] $parts[1]
+                end
+            end
+    end
+end
+
+complete -c clide -f -a '(__clide_complete)'
+
+{{define "tree" -}}
+function __clide_children
+    switch $argv[1]
+{{- range .Nodes}}{{if .Children}}
+        case {{quote .Key}}
+            printf '%s\n'{{range .Children}} {{quote .}}{{end}}
+{{- end}}{{end}}
+    end
+end
+
+function __clide_flags
+    switch $argv[1]
+{{- range .Nodes}}{{if .Flags}}
+        case {{quote .Key}}
+            printf '%s\n'{{range .Flags}} {{quote .}}{{end}}
+{{- end}}{{end}}
+    end
+end
+{{- end}}
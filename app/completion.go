@@ -0,0 +1,262 @@
+package model
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/TeddyRandby/clide/node"
+	"github.com/TeddyRandby/clide/path"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	ClideCompletionBash    = "bash"
+	ClideCompletionZsh     = "zsh"
+	ClideCompletionFish    = "fish"
+	ClideCompletionOptions = "options"
+	ClideCompletionTree    = "tree"
+)
+
+//go:embed completion.bash
+var completionBash string
+
+//go:embed completion.zsh
+var completionZsh string
+
+//go:embed completion.fish
+var completionFish string
+
+var completionScripts = map[string]string{
+	ClideCompletionBash: completionBash,
+	ClideCompletionZsh:  completionZsh,
+	ClideCompletionFish: completionFish,
+}
+
+// completionNode is a single entry in a generated completion script. Key is
+// the path of names leading to the node, like "/animalfriends/". Children
+// are formatted as name:shortcut, and Flags as -shortcut=.
+type completionNode struct {
+	Key      string
+	Children []string
+	Flags    []string
+}
+
+type completionTree struct {
+	Dir      string
+	Nodes    []completionNode
+	Builtins []string
+}
+
+// completionQuote quotes s as a single-quoted word for the given shell.
+func completionQuote(shell string) func(string) string {
+	if shell == ClideCompletionFish {
+		return func(s string) string {
+			s = strings.ReplaceAll(s, `\`, `\\`)
+			return "'" + strings.ReplaceAll(s, "'", `\'`) + "'"
+		}
+	}
+
+	return func(s string) string {
+		return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+	}
+}
+
+func completionFlags(n node.CommandNode) []string {
+	leaves := []node.CommandNode{n}
+
+	if n.Type == node.NodeTypeModule {
+		leaves = n.Leaves()
+	}
+
+	flags := make([]string, 0)
+
+	for _, leaf := range leaves {
+		for _, param := range leaf.Parameters() {
+			flag := fmt.Sprintf("-%s=", param.Shortcut)
+
+			if param.Shortcut != "" && !slices.Contains(flags, flag) {
+				flags = append(flags, flag)
+			}
+		}
+	}
+
+	return flags
+}
+
+// completionSafe reports whether name can be completed. Names with shell
+// metacharacters are left out of the script, even though it quotes them.
+func completionSafe(name string) bool {
+	return name != "" && strings.IndexFunc(name, func(r rune) bool {
+		return !(r == '_' || r == '-' || r == '.' || unicode.IsLetter(r) || unicode.IsDigit(r))
+	}) < 0
+}
+
+func completionNodes(n node.CommandNode, key string) []completionNode {
+	entry := completionNode{
+		Key:      key,
+		Children: make([]string, 0, len(n.Children)),
+		Flags:    completionFlags(n),
+	}
+
+	nodes := []completionNode{entry}
+
+	for _, child := range n.Children {
+		if !completionSafe(child.Name) || (child.Shortcut != "" && !completionSafe(child.Shortcut)) {
+			continue
+		}
+
+		nodes[0].Children = append(nodes[0].Children, child.Name+":"+child.Shortcut)
+		nodes = append(nodes, completionNodes(child, key+child.Name+"/")...)
+	}
+
+	return nodes
+}
+
+// completionStart is start as the first word sees it: its own children,
+// followed by those of the trees it falls back to which it doesn't shadow.
+func completionStart(start *node.CommandNode) node.CommandNode {
+	n := *start
+	n.Children = slices.Clone(start.Children)
+
+	for t := start; t.Parent != nil && t.Path == t.Root; t = t.Parent {
+		for _, child := range t.Parent.Children {
+			if !slices.ContainsFunc(n.Children, func(c node.CommandNode) bool { return c.Name == child.Name }) {
+				n.Children = append(n.Children, child)
+			}
+		}
+	}
+
+	return n
+}
+
+// Completion prints a completion script for the given shell, generated from
+// the command tree of the working directory. If tree is set, only the functions describing the
+// command tree are printed, so that the script can reload them when the
+// working directory changes.
+func (m Clide) Completion(shell string, tree bool) error {
+	script, ok := completionScripts[shell]
+
+	if !ok {
+		return fmt.Errorf("Unknown shell '%s'", shell)
+	}
+
+	tmpl, err := template.New(shell).
+		Funcs(template.FuncMap{"join": strings.Join, "quote": completionQuote(shell)}).
+		Parse(script)
+
+	if err != nil {
+		return err
+	}
+
+	dir, err := os.Getwd()
+
+	if err != nil {
+		return err
+	}
+
+	builtins := make([]string, len(ClideBuiltins))
+	for i, builtin := range ClideBuiltins {
+		builtins[i] = "@" + builtin
+	}
+
+	data := completionTree{
+		Dir:      dir,
+		Nodes:    make([]completionNode, 0),
+		Builtins: builtins,
+	}
+
+	if m.root != nil {
+		data.Nodes = completionNodes(completionStart(m.start()), "/")
+	}
+
+	if tree {
+		return tmpl.ExecuteTemplate(os.Stdout, "tree", data)
+	}
+
+	return tmpl.Execute(os.Stdout, data)
+}
+
+// CompletionOptions prints the values of the select parameter with the given
+// shortcut, for the command or module selected by words. Words are parsed
+// just like the command line, so earlier parameters are available to the
+// option script.
+func (m Clide) CompletionOptions(shortcut string, words []string) error {
+	args := make(map[string]string)
+
+	if m.root == nil {
+		return nil
+	}
+
+	n := m.start()
+
+	for _, word := range words {
+		if strings.HasPrefix(word, "-") {
+			name, value, found := strings.Cut(word, "=")
+
+			if found {
+				args[name[1:]] = value
+			}
+		} else if n != nil {
+			matches := n.Resolve(strings.ToLower(word))
+
+			n = nil
+
+			if len(matches) == 1 {
+				n = matches[0]
+			}
+		}
+	}
+
+	if n == nil {
+		return nil
+	}
+
+	leaves := []node.CommandNode{*n}
+
+	if n.Type == node.NodeTypeModule {
+		leaves = n.Leaves()
+	}
+
+	for _, leaf := range leaves {
+		params := leaf.Parameters()
+
+		for i, param := range params {
+			if param.Shortcut != shortcut || param.Type != node.CommandNodeParamTypeSelect {
+				continue
+			}
+
+			sibling := path.HasSibling(leaf.Path, param.Name)
+
+			if sibling == "" {
+				continue
+			}
+
+			for j := range params[:i] {
+				if value, ok := args[params[j].Shortcut]; ok {
+					params[j].Value = []string{value}
+				}
+			}
+
+			m.node = &leaf
+			m.params = params
+
+			options, err := m.options(sibling)
+
+			if err != nil {
+				return err
+			}
+
+			for _, option := range options {
+				fmt.Println(option.FilterValue())
+			}
+
+			return nil
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,326 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/TeddyRandby/clide/node"
+	"github.com/TeddyRandby/clide/path"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Config is read from clide.toml at the root of the command tree, and from
+// the config directory of the user, which takes precedence. Eg:
+//
+//	sort = "alphabetical"
+//	timeout = "1m"
+//	theme = "light"
+//
+//	[colors]
+//	purple = "#5F5FD7"
+//
+//	[keys]
+//	quit = ["ctrl+c", "ctrl+d"]
+//
+//	[interpreters]
+//	".py" = ["uv", "run"]
+type Config struct {
+	Theme        string              `toml:"theme"`
+	Sort         string              `toml:"sort"`
+	Timeout      string              `toml:"timeout"`
+	CharLimit    int                 `toml:"char_limit"`
+	Confirm      *bool               `toml:"confirm"`
+	Colors       map[string]string   `toml:"colors"`
+	Keys         map[string][]string `toml:"keys"`
+	Interpreters map[string][]string `toml:"interpreters"`
+}
+
+// config is the effective configuration, after merging every file.
+var config = DefaultConfig()
+
+// configFiles are the files config was read from, in order.
+var configFiles []string
+
+// configError is why the config could not be loaded, if it couldn't.
+var configError error
+
+// palette names the colors which may be configured.
+func palette() map[string]*lipgloss.Color {
+	return map[string]*lipgloss.Color{
+		"fg":     &fg,
+		"bg":     &bg,
+		"red":    &red,
+		"orange": &orange,
+		"yellow": &yellow,
+		"green":  &green,
+		"cyan":   &cyan,
+		"purple": &purple,
+		"pink":   &pink,
+		"white":  &white,
+		"gray":   &gray,
+		"black":  &black,
+	}
+}
+
+// bindings names the key bindings which may be configured.
+func (k *KeyMap) bindings() map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"up":         &k.Up,
+		"down":       &k.Down,
+		"next":       &k.Next,
+		"prev":       &k.Prev,
+		"root":       &k.Root,
+		"search":     &k.Search,
+		"search_all": &k.SearchAll,
+		"quit":       &k.Quit,
+		"select":     &k.Select,
+		"retry":      &k.Retry,
+		"sort":       &k.Sort,
+		"confirm":    &k.Confirm,
+		"edit":       &k.Edit,
+		"copy":       &k.Copy,
+		"vim_next":   &k.VimNext,
+		"vim_prev":   &k.VimPrev,
+		"vim_root":   &k.VimRoot,
+		"vim_quit":   &k.VimQuit,
+	}
+}
+
+// DefaultConfig is the configuration clide uses when there are no files.
+func DefaultConfig() Config {
+	confirm := false
+
+	c := Config{
+		Theme:        ClideThemeAuto,
+		Sort:         ClideSortFrecency,
+		Timeout:      DefaultTimeout.String(),
+		CharLimit:    DefaultCharLimit,
+		Confirm:      &confirm,
+		Colors:       make(map[string]string),
+		Keys:         make(map[string][]string),
+		Interpreters: make(map[string][]string),
+	}
+
+	keymap := DefaultKeyMap
+
+	for name, binding := range keymap.bindings() {
+		c.Keys[name] = binding.Keys()
+	}
+
+	for ext, argv := range node.Interpreters {
+		c.Interpreters[ext] = argv
+	}
+
+	return c
+}
+
+// merge overrides c with everything set in o.
+func (c Config) merge(o Config) Config {
+	if o.Theme != "" {
+		c.Theme = o.Theme
+	}
+
+	if o.Sort != "" {
+		c.Sort = o.Sort
+	}
+
+	if o.Timeout != "" {
+		c.Timeout = o.Timeout
+	}
+
+	if o.CharLimit != 0 {
+		c.CharLimit = o.CharLimit
+	}
+
+	if o.Confirm != nil {
+		c.Confirm = o.Confirm
+	}
+
+	for name, color := range o.Colors {
+		c.Colors[name] = color
+	}
+
+	for name, keys := range o.Keys {
+		c.Keys[name] = keys
+	}
+
+	for ext, argv := range o.Interpreters {
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+
+		c.Interpreters[ext] = argv
+	}
+
+	return c
+}
+
+func (c Config) validate() error {
+	if _, ok := themes[c.Theme]; c.Theme != "" && c.Theme != ClideThemeAuto && !ok {
+		return fmt.Errorf("theme must be %s, %s, %s, %s or %s, not '%s'", ClideThemeAuto, ClideThemeDark,
+			ClideThemeLight, ClideThemeHighContrast, ClideThemeNone, c.Theme)
+	}
+
+	if c.Sort != "" && c.Sort != ClideSortFrecency && c.Sort != ClideSortAlphabetical {
+		return fmt.Errorf("sort must be %s or %s, not '%s'", ClideSortFrecency, ClideSortAlphabetical, c.Sort)
+	}
+
+	if c.Timeout != "" {
+		if t, err := time.ParseDuration(c.Timeout); err != nil || t <= 0 {
+			return fmt.Errorf("timeout must be a duration like 30s, not '%s'", c.Timeout)
+		}
+	}
+
+	if c.CharLimit < 0 {
+		return fmt.Errorf("char_limit must not be negative")
+	}
+
+	colors := palette()
+
+	for name := range c.Colors {
+		if _, ok := colors[name]; !ok {
+			return fmt.Errorf("Unknown color '%s'", name)
+		}
+	}
+
+	bindings := DefaultKeyMap.bindings()
+
+	for name, keys := range c.Keys {
+		if _, ok := bindings[name]; !ok {
+			return fmt.Errorf("Unknown key binding '%s'", name)
+		}
+
+		if len(keys) == 0 {
+			return fmt.Errorf("Key binding '%s' has no keys", name)
+		}
+	}
+
+	for ext, argv := range c.Interpreters {
+		if len(argv) == 0 {
+			return fmt.Errorf("Interpreter for '%s' is empty", ext)
+		}
+	}
+
+	return nil
+}
+
+// readConfig reads the config file at pth, if there is one.
+func readConfig(pth string) (Config, bool, error) {
+	var c Config
+
+	meta, err := toml.DecodeFile(pth, &c)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return c, false, nil
+	}
+
+	if err == nil && len(meta.Undecoded()) > 0 {
+		err = fmt.Errorf("Unknown setting '%s'", meta.Undecoded()[0])
+	}
+
+	if err == nil {
+		err = c.validate()
+	}
+
+	if err != nil {
+		return c, true, fmt.Errorf("Invalid config %s: %s", pth, err)
+	}
+
+	return c, true, nil
+}
+
+// LoadConfig merges the defaults with the config of the command tree at
+// root, if any, and then the config of the user. CLIDE_TIMEOUT and
+// CLIDE_CONFIRM override both.
+func LoadConfig(root string) error {
+	files := make([]string, 0, 2)
+
+	if root != "" {
+		files = append(files, filepath.Join(root, path.ConfigFile))
+	}
+
+	if dir, err := path.ConfigDir(); err == nil {
+		files = append(files, filepath.Join(dir, path.ConfigFile))
+	}
+
+	c := DefaultConfig()
+	configFiles = nil
+
+	for _, file := range files {
+		f, ok, err := readConfig(file)
+
+		if err != nil {
+			configError = err
+			return err
+		}
+
+		if ok {
+			c = c.merge(f)
+			configFiles = append(configFiles, file)
+		}
+	}
+
+	if t, err := time.ParseDuration(os.Getenv("CLIDE_TIMEOUT")); err == nil && t > 0 {
+		c.Timeout = t.String()
+	}
+
+	if confirm, err := strconv.ParseBool(os.Getenv("CLIDE_CONFIRM")); err == nil {
+		c.Confirm = &confirm
+	}
+
+	config = c
+
+	applyTheme(c)
+
+	node.Interpreters = c.Interpreters
+
+	return nil
+}
+
+// keymap is DefaultKeyMap, with the keys of every configured binding
+// replaced.
+func (c Config) keymap() KeyMap {
+	keymap := DefaultKeyMap
+	bindings := keymap.bindings()
+
+	for name, keys := range c.Keys {
+		binding := bindings[name]
+
+		if strings.Join(keys, ",") == strings.Join(binding.Keys(), ",") {
+			continue
+		}
+
+		binding.SetKeys(keys...)
+		binding.SetHelp(strings.Join(keys, ","), binding.Help().Desc)
+	}
+
+	return keymap
+}
+
+// PrintConfig prints the effective configuration as TOML, noting the files
+// it was read from. The colors printed are those of the theme in use.
+func (m Clide) PrintConfig() error {
+	if configError != nil {
+		return configError
+	}
+
+	for _, file := range configFiles {
+		fmt.Printf("# %s\n", file)
+	}
+
+	c := config
+	c.Colors = c.colors()
+
+	if theme := resolveTheme(c.Theme); theme != c.Theme {
+		fmt.Printf("# theme %s is using %s\n", c.Theme, theme)
+	}
+
+	return toml.NewEncoder(os.Stdout).Encode(c)
+}
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/TeddyRandby/clide/node"
+	"github.com/TeddyRandby/clide/path"
+)
+
+// Doctor checks every tree clide would load from here, and prints the
+// problems it finds. It doesn't need the trees to load, so it can explain
+// why they don't.
+func (m Clide) Doctor() error {
+	trees, err := path.FindTrees()
+
+	if err != nil {
+		return err
+	}
+
+	if user := path.UserRoot(); user != "" && !slices.Contains(trees, user) {
+		trees = append(trees, user)
+	}
+
+	if len(trees) == 0 {
+		return fmt.Errorf("No project found, create a .clide folder or pass %s", ClideFlagRoot)
+	}
+
+	problems := make([]node.Problem, 0)
+
+	for _, tree := range trees {
+		problems = append(problems, node.Doctor(tree)...)
+	}
+
+	root, err := node.Root()
+
+	switch {
+	case err != nil && len(problems) == 0:
+		problems = append(problems, node.Problem{Path: trees[len(trees)-1], Message: err.Error()})
+	case root != nil:
+		for _, problem := range root.Problems {
+			if !slices.Contains(problems, problem) {
+				problems = append(problems, problem)
+			}
+		}
+	}
+
+	for _, problem := range problems {
+		fmt.Println(problem)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("Found %d problems", len(problems))
+	}
+
+	fmt.Println("No problems found")
+
+	return nil
+}
@@ -0,0 +1,283 @@
+package model
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TeddyRandby/clide/path"
+)
+
+// HistoryLimit is the number of invocations kept in the history of each
+// project.
+const HistoryLimit = 1000
+
+// HistoryEntry is a command which was run, along with the values of its
+// parameters keyed by name.
+type HistoryEntry struct {
+	Steps  []string            `json:"steps"`
+	Params map[string][]string `json:"params"`
+	Args   []string            `json:"args,omitempty"`
+	Time   time.Time           `json:"time"`
+	Cwd    string              `json:"cwd"`
+}
+
+func (e HistoryEntry) String() string {
+	params := make([]string, 0, len(e.Params))
+
+	for name, values := range e.Params {
+		params = append(params, fmt.Sprintf("%s=%s", name, strings.Join(values, ",")))
+	}
+
+	sort.Strings(params)
+
+	if len(e.Args) > 0 {
+		params = append(params, ClideArgsSeparator)
+
+		for _, arg := range e.Args {
+			params = append(params, shellQuote(arg))
+		}
+	}
+
+	return fmt.Sprintf("%s\t%s\t%s\t%s",
+		e.Time.Local().Format("2006-01-02 15:04:05"),
+		strings.Join(e.Steps, " "),
+		strings.Join(params, " "),
+		e.Cwd,
+	)
+}
+
+// historyFile is the history of the project at root. Projects are told apart
+// by a hash of their path.
+func historyFile(root string) (string, error) {
+	dir, err := path.StateDir()
+
+	if err != nil {
+		return "", err
+	}
+
+	project := filepath.Dir(root)
+	sum := sha256.Sum256([]byte(project))
+
+	name := fmt.Sprintf("%s-%s.jsonl", filepath.Base(project), hex.EncodeToString(sum[:4]))
+
+	return filepath.Join(dir, "history", name), nil
+}
+
+// history reads the history of the current project, oldest first.
+func (m Clide) history() ([]HistoryEntry, error) {
+	file, err := historyFile(m.root.Path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(file)
+
+	if os.IsNotExist(err) {
+		return []HistoryEntry{}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	entries := make([]HistoryEntry, 0)
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		var entry HistoryEntry
+
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err == nil {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, scanner.Err()
+}
+
+// record appends the command about to be run to the history of the project.
+// History is best effort, so failures are ignored.
+func (m Clide) record() {
+	file, err := historyFile(m.root.Path)
+
+	if err != nil {
+		return
+	}
+
+	entries, err := m.history()
+
+	if err != nil {
+		return
+	}
+
+	cwd, _ := os.Getwd()
+
+	entry := HistoryEntry{
+		Steps:  m.node.StepNames(),
+		Params: make(map[string][]string, len(m.params)),
+		Args:   m.extra,
+		Time:   time.Now(),
+		Cwd:    cwd,
+	}
+
+	for _, param := range m.params {
+		entry.Params[param.Name] = param.Value
+	}
+
+	entries = append(entries, entry)
+
+	if len(entries) > HistoryLimit {
+		entries = entries[len(entries)-HistoryLimit:]
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return
+	}
+
+	var b strings.Builder
+
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+
+		if err != nil {
+			return
+		}
+
+		b.Write(line)
+		b.WriteByte('\n')
+	}
+
+	tmp := file + ".tmp"
+
+	if err := os.WriteFile(tmp, []byte(b.String()), 0o644); err != nil {
+		return
+	}
+
+	os.Rename(tmp, file)
+}
+
+// History prints the history of the project, most recent first. The numbers
+// printed are the ones accepted by Rerun.
+func (m Clide) History() error {
+	entries, err := m.history()
+
+	if err != nil {
+		return err
+	}
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		fmt.Printf("%d\t%s\n", len(entries)-i, entries[i])
+	}
+
+	return nil
+}
+
+// historyArg parses the optional history entry number given to a builtin,
+// which defaults to the most recent entry.
+func historyArg(args []string) (int, error) {
+	if len(args) == 0 {
+		return 1, nil
+	}
+
+	n, err := strconv.Atoi(args[0])
+
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("Invalid history entry '%s'", args[0])
+	}
+
+	return n, nil
+}
+
+// entry is the nth most recent command in history.
+func (m Clide) entry(n int) (HistoryEntry, error) {
+	entries, err := m.history()
+
+	if err != nil {
+		return HistoryEntry{}, err
+	}
+
+	if n > len(entries) {
+		return HistoryEntry{}, fmt.Errorf("No history entry %d", n)
+	}
+
+	return entries[len(entries)-n], nil
+}
+
+// from loads the tree again from dir, where a command in history ran. The
+// trees of subprojects are only nested when clide runs inside them.
+func (m Clide) from(dir string) Clide {
+	if dir == "" || !path.Exists(dir) || os.Chdir(dir) != nil {
+		return m
+	}
+
+	c := New(m.args)
+
+	c.headless = m.headless
+	c.confirm = m.confirm
+	c.extra = m.extra
+
+	return c
+}
+
+// Rerun runs the nth most recent command in history again, with the same
+// parameters and working directory, without prompting.
+func (m Clide) Rerun(n int) {
+	entry, err := m.entry(n)
+
+	if err != nil {
+		m, _ := m.Error(err.Error())
+		m.Run()
+		return
+	}
+
+	if m = m.from(entry.Cwd); !m.Ok() {
+		m.Run()
+		return
+	}
+
+	// The values were recorded after multi-select arguments were split, so
+	// they are escaped to come back whole.
+	m.args = entry.Params
+
+	if cmd := m.find(entry.Steps); cmd != nil {
+		for _, param := range cmd.Parameters() {
+			if values, ok := entry.Params[param.Name]; ok && param.Multi {
+				escaped := make([]string, len(values))
+
+				for i, value := range values {
+					escaped[i] = escapeMulti(value)
+				}
+
+				m.args[param.Name] = escaped
+			}
+		}
+	}
+
+	m.extra = entry.Args
+	m.headless = true
+
+	c, _ := m.Root()
+
+	for _, step := range entry.Steps {
+		c, _ = c.SelectPath(step)
+
+		if !c.Ok() {
+			break
+		}
+	}
+
+	c.Run()
+}
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/TeddyRandby/clide/node"
+	"github.com/TeddyRandby/clide/path"
+)
+
+type lsParameter struct {
+	Name      string `json:"name"`
+	Shortcut  string `json:"shortcut"`
+	Type      string `json:"type"`
+	Format    string `json:"format"`
+	Multi     bool   `json:"multi"`
+	HasScript bool   `json:"has_script"`
+}
+
+type lsLeaf struct {
+	Name         string        `json:"name"`
+	Shortcut     string        `json:"shortcut"`
+	Path         string        `json:"path"`
+	RelativePath string        `json:"relative_path"`
+	Steps        string        `json:"steps"`
+	Description  string        `json:"description"`
+	Aliases      []string      `json:"aliases"`
+	Help         string        `json:"help"`
+	Parameters   []lsParameter `json:"parameters"`
+}
+
+func newLsLeaf(leaf node.CommandNode) lsLeaf {
+	params := leaf.Parameters()
+
+	l := lsLeaf{
+		Name:         leaf.Name,
+		Shortcut:     leaf.Shortcut,
+		Path:         leaf.Path,
+		RelativePath: leaf.RelativePath(),
+		Steps:        leaf.Steps(),
+		Description:  leaf.Meta.Description,
+		Aliases:      leaf.Meta.Aliases,
+		Help:         leaf.Meta.Help,
+		Parameters:   make([]lsParameter, len(params)),
+	}
+
+	for i, param := range params {
+		l.Parameters[i] = lsParameter{
+			Name:      param.Name,
+			Shortcut:  param.Shortcut,
+			Type:      param.Type,
+			Format:    param.Format,
+			Multi:     param.Multi,
+			HasScript: path.HasSibling(leaf.Path, param.Name) != "",
+		}
+	}
+
+	return l
+}
+
+// listJSON prints every leaf, with its parameters, as a JSON array.
+func (m Clide) listJSON(leaves []node.CommandNode) error {
+	out := make([]lsLeaf, len(leaves))
+
+	for i, leaf := range leaves {
+		out[i] = newLsLeaf(leaf)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(out)
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"context"
 	_ "embed"
 	"fmt"
 	"os"
@@ -8,36 +9,49 @@ import (
 	"syscall"
 
 	"github.com/TeddyRandby/clide/node"
+	"github.com/TeddyRandby/clide/path"
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
+	"golang.org/x/exp/slices"
 )
 
 const (
 	ClideStateStart = iota
 	ClideStatePathSelect
+	ClideStateSearch
 	ClideStatePromptSelect
 	ClideStatePromptInput
+	ClideStatePromptArgs
+	ClideStateLoading
+	ClideStateReview
 	ClideStateError
 	ClideStateDone
 )
 
 type KeyMap struct {
-	Up      key.Binding
-	Down    key.Binding
-	Next    key.Binding
-	Prev    key.Binding
-	Root    key.Binding
-	Search  key.Binding
-	Quit    key.Binding
-	Select  key.Binding
-	VimNext key.Binding
-	VimPrev key.Binding
-	VimRoot key.Binding
-	VimQuit key.Binding
+	Up        key.Binding
+	Down      key.Binding
+	Next      key.Binding
+	Prev      key.Binding
+	Root      key.Binding
+	Search    key.Binding
+	SearchAll key.Binding
+	Quit      key.Binding
+	Select    key.Binding
+	Retry     key.Binding
+	Sort      key.Binding
+	Confirm   key.Binding
+	Edit      key.Binding
+	Copy      key.Binding
+	VimNext   key.Binding
+	VimPrev   key.Binding
+	VimRoot   key.Binding
+	VimQuit   key.Binding
 }
 
 var DefaultKeyMap = KeyMap{
@@ -65,6 +79,10 @@ var DefaultKeyMap = KeyMap{
 		key.WithKeys("/"),
 		key.WithHelp("/", "search"),
 	),
+	SearchAll: key.NewBinding(
+		key.WithKeys("ctrl+f"),
+		key.WithHelp("ctrl+f", "search all"),
+	),
 	Quit: key.NewBinding(
 		key.WithKeys("ctrl+c"),
 		key.WithHelp("ctrl+c", "quit"),
@@ -89,6 +107,26 @@ var DefaultKeyMap = KeyMap{
 		key.WithKeys(" "),
 		key.WithHelp("space", "select"),
 	),
+	Retry: key.NewBinding(
+		key.WithKeys("R"),
+		key.WithHelp("R", "retry"),
+	),
+	Sort: key.NewBinding(
+		key.WithKeys("ctrl+o"),
+		key.WithHelp("ctrl+o", "sort"),
+	),
+	Confirm: key.NewBinding(
+		key.WithKeys("enter", "y"),
+		key.WithHelp("enter,y", "run"),
+	),
+	Edit: key.NewBinding(
+		key.WithKeys("1", "2", "3", "4", "5", "6", "7", "8", "9"),
+		key.WithHelp("1-9", "edit"),
+	),
+	Copy: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "copy command"),
+	),
 }
 
 func (k KeyMap) ShortHelp() []key.Binding {
@@ -97,7 +135,7 @@ func (k KeyMap) ShortHelp() []key.Binding {
 
 func (k KeyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
-		{k.Up, k.Down, k.Next, k.Prev, k.Root, k.Quit},
+		{k.Up, k.Down, k.Next, k.Prev, k.Root, k.SearchAll, k.Sort, k.Quit},
 		{k.VimNext, k.VimPrev, k.VimRoot, k.VimQuit},
 	}
 }
@@ -105,6 +143,8 @@ func (k KeyMap) FullHelp() [][]key.Binding {
 type Clide struct {
 	state    int
 	error    string
+	detail   string
+	invalid  string
 	ready    bool
 	width    int
 	height   int
@@ -112,37 +152,96 @@ type Clide struct {
 	textarea textarea.Model
 	list     list.Model
 	spinner  spinner.Model
+	viewport viewport.Model
 	node     *node.CommandNode
 	root     *node.CommandNode
 	params   []node.CommandNodeParameter
 	param    int
-	args     map[string]string
+	args     map[string][]string
+	headless bool
 	keymap   KeyMap
+	sibling  string
+	loading  int
+	cancel   context.CancelFunc
+	pending  tea.Cmd
+	usage    usages
+	sort     string
+	editing  bool
+	confirm  bool
+	extra    []string
+	notice   string
+
+	// ambiguous is the word the menu is disambiguating, if any.
+	ambiguous string
 }
 
 func (m Clide) Init() tea.Cmd {
-	return m.spinner.Tick
+	if m.state == ClideStateLoading {
+		return tea.Batch(m.spinner.Tick, m.pending)
+	}
+
+	return nil
 }
 
 func (m Clide) Leaves() []node.CommandNode {
-	return m.node.Leaves()
+	return m.root.Leaves()
 }
 
 func (m Clide) Ok() bool {
 	return m.state != ClideStateError
 }
 
+// Ambiguous reports whether clide is asking which of several commands or
+// modules a word meant, so the words after it can't be followed yet.
+func (m Clide) Ambiguous() bool {
+	return m.ambiguous != ""
+}
+
 func (m Clide) Err() string {
 	return m.error
 }
 
-func New(args map[string]string) Clide {
+// Headless returns a copy of m which never opens the interface. Instead,
+// missing parameters and errors are reported on stderr.
+func (m Clide) Headless() Clide {
+	m.headless = true
+	return m
+}
+
+// Passthrough returns a copy of m which passes args on to the command it
+// runs, after its name.
+func (m Clide) Passthrough(args []string) Clide {
+	m.extra = args
+	return m
+}
+
+// Confirmed returns a copy of m which runs dangerous commands without asking
+// for confirmation first.
+func (m Clide) Confirmed() Clide {
+	m.confirm = true
+	return m
+}
+
+func New(args map[string][]string) Clide {
+	// The config of the user still applies when there is no project, or the
+	// tree is broken.
+	rootPath, _ := path.FindRoot()
+
+	if err := LoadConfig(rootPath); err != nil {
+		m, _ := Clide{
+			args:   args,
+			keymap: DefaultKeyMap,
+			help:   help.New(),
+		}.Error(err.Error())
+		return m
+	}
+
 	root, err := node.Root()
 
 	if err != nil {
 		m, _ := Clide{
 			args:   args,
-			keymap: DefaultKeyMap,
+			keymap: config.keymap(),
 			help:   help.New(),
 		}.Error(err.Error())
 		return m
@@ -151,39 +250,217 @@ func New(args map[string]string) Clide {
 	if root == nil {
 		m, _ := Clide{
 			args:   args,
-			keymap: DefaultKeyMap,
+			keymap: config.keymap(),
 			help:   help.New(),
-		}.Error("No project found")
+		}.Error(fmt.Sprintf("No project found, create a .clide folder or pass %s", ClideFlagRoot))
 		return m
 	}
 
-	m, _ := Clide{
+	// Broken commands and modules are shown in the menu, but nothing is left
+	// when the root itself is broken.
+	if root.Err != nil {
+		m, _ := Clide{
+			args:   args,
+			keymap: config.keymap(),
+			help:   help.New(),
+		}.Error(fmt.Sprintf("%s can't be used: %s", root.Path, root.Err))
+		return m
+	}
+
+	m := Clide{
 		root:   root,
 		node:   root,
 		args:   args,
-		keymap: DefaultKeyMap,
+		keymap: config.keymap(),
 		help:   help.New(),
-	}.PromptPath(root)
+		sort:   config.Sort,
+	}
+
+	m.usage = m.frecency()
+
+	m, _ = m.PromptPath(m.start())
 
 	return m
 }
 
-func (m Clide) env() []string {
-	env := os.Environ()
+// start is where the menu opens, and where the words on the command line are
+// resolved from. Inside a subproject, that is its own tree.
+func (m Clide) start() *node.CommandNode {
+	if trees, _ := path.FindTrees(); len(trees) > 0 {
+		if tree := m.root.Tree(trees[0]); tree != nil {
+			return tree
+		}
+	}
+
+	return m.root
+}
 
-	env = append(env, "CLIDE_PATH="+m.root.Path)
+// injected is the environment clide adds for the command: the root of its
+// tree, and the value of each parameter.
+func (m Clide) injected() []string {
+	env := []string{"CLIDE_PATH=" + m.node.Root}
 
 	for i := 0; i < len(m.params); i++ {
-    val := strings.Join(m.params[i].Value, "\n")
+		val := strings.Join(m.params[i].Value, "\n")
 		env = append(env, m.params[i].Name+"="+val)
 	}
 
 	return env
 }
 
+func (m Clide) env() []string {
+	return append(os.Environ(), m.injected()...)
+}
+
+// find follows steps from the root, like the command line does.
+func (m Clide) find(steps []string) *node.CommandNode {
+	n := m.root
+
+	for _, step := range steps {
+		n = n.Match(strings.ToLower(step))
+
+		if n == nil {
+			return nil
+		}
+	}
+
+	return n
+}
+
+// helpCommand prints the metadata, parameters and help text of n.
+func (m Clide) helpCommand(n node.CommandNode) {
+	fmt.Println(n.Title())
+
+	if n.Meta.Description != "" {
+		fmt.Printf("  %s\n", n.Meta.Description)
+	}
+
+	fmt.Println()
+
+	usage := []string{"clide"}
+
+	params := n.Parameters()
+
+	for _, param := range params {
+		if param.Shortcut != "" {
+			usage = append(usage, fmt.Sprintf("-%s=<%s>", param.Shortcut, param.Name))
+		}
+	}
+
+	usage = append(usage, n.Steps())
+
+	if n.Meta.Args != "" {
+		usage = append(usage, ClideArgsSeparator, "<args>")
+	}
+
+	fmt.Printf("Usage:   %s\n", strings.Join(usage, " "))
+	fmt.Printf("Path:    %s\n", n.RelativePath())
+
+	if len(n.Meta.Aliases) > 0 {
+		fmt.Printf("Aliases: %s\n", strings.Join(n.Meta.Aliases, ", "))
+	}
+
+	if n.Meta.Args != "" {
+		fmt.Printf("Args:    %s\n", n.Meta.Args)
+	}
+
+	if n.Type == node.NodeTypeModule {
+		fmt.Println("\nCommands:")
+
+		for _, child := range n.Children {
+			fmt.Printf("  %s\t%s\n", child.Title(), child.Description())
+		}
+	}
+
+	if len(params) > 0 {
+		fmt.Println("\nParameters:")
+
+		for _, param := range params {
+			script := ""
+			if path.HasSibling(n.Path, param.Name) != "" {
+				script = " (script)"
+			}
+
+			hint := ""
+			if param.Hint() != "" {
+				hint = ", " + param.Hint()
+			}
+
+			fmt.Printf("  -%s\t%s\t%s%s%s\n", param.Shortcut, param.Name, param.Type, hint, script)
+		}
+	}
+
+	if n.Meta.Help != "" {
+		fmt.Printf("\n%s\n", n.Meta.Help)
+	}
+}
+
+// fail reports why m could not reach a command, and exits.
+func (m Clide) fail() {
+	err := m.error
+
+	if m.state != ClideStateError {
+		names := make([]string, len(m.node.Children))
+
+		for i, child := range m.node.Children {
+			names[i] = child.Name
+		}
+
+		err = fmt.Sprintf("No command selected, expected one of: %s", strings.Join(names, ", "))
+	}
+
+	fmt.Fprintf(os.Stderr, "clide: %s\n", err)
+
+	if m.detail != "" {
+		fmt.Fprintln(os.Stderr, m.detail)
+	}
+
+	os.Exit(1)
+}
+
+// exec records the command in history, and replaces clide with it.
+func (m Clide) exec() {
+	prog, argv, err := node.Interpret(m.node.Path, m.node.Meta.Interpreter)
+
+	if err != nil {
+		m, _ := m.Error(err.Error())
+		m.fail()
+	}
+
+	dir, err := m.node.Workdir()
+
+	if err != nil {
+		m, _ := m.Error(err.Error())
+		m.fail()
+	}
+
+	if prog == m.node.Path {
+		argv[0] = m.node.Name
+	}
+
+	m.record()
+
+	if dir != "" {
+		if err := os.Chdir(dir); err != nil {
+			m, _ := m.Error(err.Error())
+			m.fail()
+		}
+	}
+
+	err = syscall.Exec(prog, append(argv, m.extra...), m.env())
+
+	m, _ = m.Error(fmt.Sprintf("Could not execute %s: %s", m.node.Path, err))
+	m.fail()
+}
+
 func (m Clide) Run() {
 	if m.state == ClideStateDone {
-		syscall.Exec(m.node.Path, []string{m.node.Name}, m.env())
+		m.exec()
+		return
+	}
+
+	if m.headless {
+		m.fail()
 		return
 	}
 
@@ -197,28 +474,160 @@ func (m Clide) Run() {
 	m = c.(Clide)
 
 	if m.state == ClideStateDone {
-		syscall.Exec(m.node.Path, []string{m.node.Name}, m.env())
+		// Teach the shortcuts for the command which was just chosen.
+		fmt.Fprintln(os.Stderr, m.commandLine())
+		m.exec()
 	}
 }
 
 const (
-	ClideBuiltinLS   = "ls"
-	ClideBuiltinHelp = "help"
+	ClideBuiltinLS         = "ls"
+	ClideBuiltinHelp       = "help"
+	ClideBuiltinCompletion = "completion"
+	ClideBuiltinRun        = "run"
+	ClideBuiltinHistory    = "history"
+	ClideBuiltinRerun      = "rerun"
+	ClideBuiltinLast       = "last"
+	ClideBuiltinConfig     = "config"
+	ClideBuiltinDoctor     = "doctor"
 )
 
+const (
+	ClideFlagJSON = "--json"
+	ClideFlagYes  = "--yes"
+	ClideFlagRoot = "--root"
+)
+
+// ClideArgsSeparator ends the arguments for clide. Everything after it is
+// passed on to the command.
+const ClideArgsSeparator = "--"
+
+var ClideBuiltins = []string{
+	ClideBuiltinLS,
+	ClideBuiltinHelp,
+	ClideBuiltinCompletion,
+	ClideBuiltinRun,
+	ClideBuiltinHistory,
+	ClideBuiltinRerun,
+	ClideBuiltinLast,
+	ClideBuiltinConfig,
+	ClideBuiltinDoctor,
+}
+
 //go:embed help.md
 var ClideHelp string
 
-func (m Clide) Builtin(cmd string) {
+func (m Clide) Builtin(cmd string, args []string) {
 	switch cmd {
 	case ClideBuiltinHelp:
-		fmt.Println(ClideHelp)
+		if len(args) == 0 {
+			fmt.Println(ClideHelp)
+			return
+		}
+
+		if !m.Ok() {
+			m.Run()
+			return
+		}
+
+		n := m.find(args)
+
+		if n == nil {
+			m, _ := m.Error(fmt.Sprintf("No command or module '%s'", strings.Join(args, " ")))
+			m.Run()
+			return
+		}
+
+		m.helpCommand(*n)
 	case ClideBuiltinLS:
+		if !m.Ok() {
+			m.Run()
+			return
+		}
+
 		leaves := m.Leaves()
 
+		if slices.Contains(args, ClideFlagJSON) {
+			if err := m.listJSON(leaves); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+
 		for _, leaf := range leaves {
 			fmt.Printf("%s\t%s\t%s\n", leaf.Title(), leaf.Description(), leaf.Steps())
 		}
+	case ClideBuiltinCompletion:
+		if len(args) == 0 {
+			m, _ := m.Error("Usage: clide @completion bash|zsh|fish")
+			m.Run()
+			return
+		}
+
+		var err error
+
+		if args[0] == ClideCompletionOptions && len(args) > 1 {
+			err = m.CompletionOptions(args[1], args[2:])
+		} else {
+			err = m.Completion(args[0], len(args) > 1 && args[1] == ClideCompletionTree)
+		}
+
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	case ClideBuiltinHistory:
+		if !m.Ok() {
+			m.Run()
+			return
+		}
+
+		if err := m.History(); err != nil {
+			m, _ := m.Error(err.Error())
+			m.Run()
+		}
+	case ClideBuiltinRerun:
+		if !m.Ok() {
+			m.Run()
+			return
+		}
+
+		n, err := historyArg(args)
+
+		if err != nil {
+			m, _ := m.Error(err.Error())
+			m.Run()
+			return
+		}
+
+		m.Rerun(n)
+	case ClideBuiltinLast:
+		if !m.Ok() {
+			m.Run()
+			return
+		}
+
+		n, err := historyArg(args)
+
+		if err == nil {
+			err = m.Last(n)
+		}
+
+		if err != nil {
+			m, _ := m.Error(err.Error())
+			m.Run()
+		}
+	case ClideBuiltinConfig:
+		if err := m.PrintConfig(); err != nil {
+			m, _ := m.Error(err.Error())
+			m.Run()
+		}
+	case ClideBuiltinDoctor:
+		if err := m.Doctor(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
 	default:
 		m, _ := m.Error(fmt.Sprintf("Unknown builtin command '%s'", cmd))
 		m.Run()
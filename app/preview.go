@@ -0,0 +1,271 @@
+package model
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/TeddyRandby/clide/node"
+	"github.com/TeddyRandby/clide/path"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// PreviewMinWidth is the narrowest window which has room for the preview
+// pane beside the list.
+const PreviewMinWidth = 80
+
+// keywords are highlighted in the preview. They are shared by the shells and
+// scripting languages commonly used for commands.
+var keywords = map[string]bool{
+	"if": true, "then": true, "else": true, "elif": true, "fi": true,
+	"for": true, "while": true, "until": true, "do": true, "done": true,
+	"case": true, "esac": true, "in": true, "function": true, "return": true,
+	"local": true, "export": true, "set": true, "exit": true, "echo": true,
+	"printf": true, "cd": true, "source": true, "def": true, "import": true,
+	"from": true, "class": true, "const": true, "let": true, "var": true,
+	"func": true, "package": true, "end": true, "elsif": true,
+}
+
+// selectedNode is the command or module under the cursor.
+func (m Clide) selectedNode() (node.CommandNode, bool) {
+	switch i := m.list.SelectedItem().(type) {
+	case node.CommandNode:
+		return i, true
+	case leafItem:
+		return i.node, true
+	}
+
+	return node.CommandNode{}, false
+}
+
+// previewView shows what the highlighted command does before it is run: its
+// metadata, parameters and source. Modules show what they contain.
+func (m Clide) previewView(width, height int) string {
+	n, ok := m.selectedNode()
+
+	if !ok {
+		return previewStyle.Copy().Height(height).Render("")
+	}
+
+	innerWidth := max(width-previewStyle.GetHorizontalFrameSize(), 0)
+
+	lines := []string{previewTitle.Render(n.Title())}
+
+	if n.Err != nil {
+		lines = append(lines, invalidStyle.Copy().Width(innerWidth).Render(n.Err.Error()), previewLabel.Render(n.Path))
+
+		return previewStyle.Copy().Height(height).MaxHeight(height).MaxWidth(width).Render(strings.Join(lines, "\n"))
+	}
+
+	if n.Meta.Description != "" {
+		lines = append(lines, previewText.Render(n.Meta.Description))
+	}
+
+	lines = append(lines, previewLabel.Render(n.RelativePath()), "")
+
+	if len(n.Meta.Aliases) > 0 {
+		lines = append(lines, previewLabel.Render("Aliases: ")+previewText.Render(strings.Join(n.Meta.Aliases, ", ")))
+	}
+
+	if n.Type == node.NodeTypeModule {
+		lines = append(lines, previewText.Render(moduleSummary(n)))
+	} else {
+		lines = append(lines, previewParameters(n)...)
+
+		if n.Meta.Help != "" {
+			lines = append(lines, previewText.Copy().Width(innerWidth).Render(n.Meta.Help), "")
+		}
+
+		lines = append(lines, highlight(n.Path, height)...)
+	}
+
+	return previewStyle.
+		Copy().
+		Height(height).
+		MaxHeight(height).
+		MaxWidth(width).
+		Render(strings.Join(lines, "\n"))
+}
+
+// moduleSummary counts the commands and modules directly under n.
+func moduleSummary(n node.CommandNode) string {
+	commands, modules := 0, 0
+
+	for _, child := range n.Children {
+		if child.Type == node.NodeTypeModule {
+			modules++
+		} else {
+			commands++
+		}
+	}
+
+	return fmt.Sprintf("%d commands, %d modules, %d commands in total", commands, modules, len(n.Leaves()))
+}
+
+func previewParameters(n node.CommandNode) []string {
+	params := n.Parameters()
+
+	if len(params) == 0 {
+		return nil
+	}
+
+	lines := []string{previewLabel.Render("Parameters:")}
+
+	for _, param := range params {
+		desc := param.Type
+
+		if param.Multi {
+			desc += ", multiple"
+		}
+
+		if param.Hint() != "" {
+			desc += ", " + param.Hint()
+		}
+
+		if path.HasSibling(n.Path, param.Name) != "" {
+			desc += " (script)"
+		}
+
+		flag := param.Name
+		if param.Shortcut != "" {
+			flag = fmt.Sprintf("-%s %s", param.Shortcut, param.Name)
+		}
+
+		lines = append(lines, "  "+previewText.Render(flag)+" "+previewLabel.Render(desc))
+	}
+
+	return append(lines, "")
+}
+
+// highlight reads up to limit lines of the script at pth, colouring its
+// shebang, comments, strings, variables and keywords.
+func highlight(pth string, limit int) []string {
+	f, err := os.Open(pth)
+
+	if err != nil {
+		return []string{commentStyle.Render(err.Error())}
+	}
+
+	defer f.Close()
+
+	lines := make([]string, 0, limit)
+
+	scanner := bufio.NewScanner(f)
+
+	for i := 0; i < limit && scanner.Scan(); i++ {
+		line := strings.ReplaceAll(scanner.Text(), "\t", "  ")
+
+		if i == 0 && strings.HasPrefix(line, "#!") {
+			lines = append(lines, shebangStyle.Render(line))
+			continue
+		}
+
+		lines = append(lines, highlightLine(line))
+	}
+
+	return lines
+}
+
+func highlightLine(line string) string {
+	var b, plain strings.Builder
+
+	// Plain text is buffered, so that it is styled once per run rather than
+	// once per rune.
+	flush := func() {
+		if plain.Len() > 0 {
+			b.WriteString(codeStyle.Render(plain.String()))
+			plain.Reset()
+		}
+	}
+
+	runes := []rune(line)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		rest := string(runes[i:])
+
+		switch {
+		case r == '#' && (i == 0 || unicode.IsSpace(runes[i-1])),
+			strings.HasPrefix(rest, "//") && (i == 0 || unicode.IsSpace(runes[i-1])),
+			strings.HasPrefix(strings.TrimSpace(line), "--") && strings.TrimSpace(string(runes[:i])) == "":
+			flush()
+			b.WriteString(commentStyle.Render(rest))
+			return b.String()
+
+		case r == '"' || r == '\'' || r == '`':
+			j := i + 1
+			for j < len(runes) && runes[j] != r {
+				if runes[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			j = min(j+1, len(runes))
+
+			flush()
+			b.WriteString(stringStyle.Render(string(runes[i:j])))
+			i = j
+
+		case r == '$':
+			j := i + 1
+			if j < len(runes) && runes[j] == '{' {
+				for j < len(runes) && runes[j] != '}' {
+					j++
+				}
+				j = min(j+1, len(runes))
+			} else {
+				for j < len(runes) && (runes[j] == '_' || unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j])) {
+					j++
+				}
+			}
+
+			flush()
+			b.WriteString(variableStyle.Render(string(runes[i:j])))
+			i = j
+
+		case unicode.IsLetter(r) || r == '_':
+			j := i
+			for j < len(runes) && (runes[j] == '_' || unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j])) {
+				j++
+			}
+
+			word := string(runes[i:j])
+
+			if keywords[word] {
+				flush()
+				b.WriteString(keywordStyle.Render(word))
+			} else {
+				plain.WriteString(word)
+			}
+			i = j
+
+		default:
+			plain.WriteRune(r)
+			i++
+		}
+	}
+
+	flush()
+
+	return b.String()
+}
+
+// splitView places the list beside the preview, when the window is wide
+// enough for both.
+func (m Clide) splitView(height int) string {
+	if m.width < PreviewMinWidth {
+		m.list.SetSize(m.width, height)
+		return m.list.View()
+	}
+
+	listWidth := m.width / 2
+
+	m.list.SetSize(listWidth, height)
+
+	return lipgloss.JoinHorizontal(lipgloss.Top,
+		lipgloss.NewStyle().Width(listWidth).Render(m.list.View()),
+		m.previewView(m.width-listWidth, height),
+	)
+}
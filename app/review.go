@@ -0,0 +1,123 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/TeddyRandby/clide/node"
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/muesli/termenv"
+)
+
+// reviewEnabled reports whether every command should be reviewed before it
+// runs, as set by CLIDE_CONFIRM or the config.
+func reviewEnabled() bool {
+	return config.Confirm != nil && *config.Confirm
+}
+
+// reviewing reports whether the command must be confirmed before it runs.
+// Dangerous commands are always reviewed, unless they were confirmed on the
+// command line.
+func (m Clide) reviewing() bool {
+	return !m.headless && !m.confirm && (m.node.Meta.Dangerous || reviewEnabled())
+}
+
+// Review shows the command which is about to run, along with its parameters
+// and environment.
+func (m Clide) Review() (Clide, tea.Cmd) {
+	c := Clide{
+		ready:    m.ready,
+		width:    m.width,
+		height:   m.height,
+		node:     m.node,
+		root:     m.root,
+		params:   m.params,
+		param:    len(m.params),
+		args:     m.args,
+		headless: m.headless,
+		keymap:   m.keymap,
+		usage:    m.usage,
+		sort:     m.sort,
+		confirm:  m.confirm,
+		extra:    m.extra,
+		help:     m.help,
+		state:    ClideStateReview,
+		viewport: viewport.New(m.width, m.height),
+	}
+
+	return c.resize(), nil
+}
+
+// Edit prompts for the nth parameter again, and then returns to the review.
+func (m Clide) Edit(n int) (Clide, tea.Cmd) {
+	if n < 0 || n >= len(m.params) {
+		return m, nil
+	}
+
+	m.param = n
+	m.editing = true
+
+	switch m.Param().Type {
+	case node.CommandNodeParamTypeInput:
+		return m.promptInput(strings.Join(m.Param().Value, "\n"))
+	case node.CommandNodeParamTypeSelect:
+		return m.PromptSelect()
+	}
+
+	return m.Error("Invalid parameter type")
+}
+
+// Copy puts the equivalent command line on the clipboard. Terminals which
+// support OSC 52 are used when there is no system clipboard.
+func (m Clide) Copy() (Clide, tea.Cmd) {
+	if err := clipboard.WriteAll(m.commandLine()); err != nil {
+		termenv.Copy(m.commandLine())
+	}
+
+	m.notice = "Copied " + m.commandLine()
+
+	return m, nil
+}
+
+// reviewContent lists everything about the command which is about to run.
+func (m Clide) reviewContent() string {
+	width := max(m.width-reviewStyle.GetHorizontalFrameSize(), 0)
+
+	lines := []string{
+		reviewLabel.Render("Run: ") + m.node.Steps(),
+		reviewLabel.Render("Path: ") + m.node.RelativePath(),
+	}
+
+	if m.node.Meta.Dangerous {
+		lines = append(lines, "", dangerStyle.Render("This command is marked as dangerous."))
+	}
+
+	if len(m.params) > 0 {
+		lines = append(lines, "", reviewLabel.Render("Parameters:"))
+
+		for i, param := range m.params {
+			flag := param.Name
+			if param.Shortcut != "" {
+				flag = fmt.Sprintf("-%s %s", param.Shortcut, param.Name)
+			}
+
+			lines = append(lines, fmt.Sprintf("  %d  %s = %s", i+1, flag, strings.Join(param.Value, ", ")))
+		}
+	}
+
+	if len(m.extra) > 0 {
+		lines = append(lines, "", reviewLabel.Render("Arguments: ")+strings.Join(m.extra, " "))
+	}
+
+	lines = append(lines, "", reviewLabel.Render("Environment:"))
+
+	for _, env := range m.injected() {
+		lines = append(lines, "  "+strings.ReplaceAll(env, "\n", "\\n"))
+	}
+
+	lines = append(lines, "", reviewLabel.Render("Command:"), "  "+m.commandLine())
+
+	return reviewStyle.Copy().Width(width).Render(strings.Join(lines, "\n"))
+}
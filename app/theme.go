@@ -0,0 +1,134 @@
+package model
+
+import (
+	"os"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+const (
+	ClideThemeAuto         = "auto"
+	ClideThemeDark         = "dark"
+	ClideThemeLight        = "light"
+	ClideThemeHighContrast = "high-contrast"
+	ClideThemeNone         = "none"
+)
+
+// themes are palettes for every color in the interface. The high contrast
+// theme keeps text in the default color of the terminal, which contrasts
+// best with its background, and uses the bright ANSI colors for accents. An
+// empty color is not rendered at all.
+var themes = map[string]map[string]string{
+	ClideThemeDark: {
+		"fg":     "#F8F8F2",
+		"bg":     "#282A36",
+		"red":    "#FF5555",
+		"orange": "#FFB86C",
+		"yellow": "#F1FA8C",
+		"green":  "#50FA7B",
+		"cyan":   "#8BE9FD",
+		"purple": "#BD93F9",
+		"pink":   "#FF79C6",
+		"white":  "#ABB2BF",
+		"gray":   "#6272A4",
+		"black":  "#191A21",
+	},
+	ClideThemeLight: {
+		"fg":     "#1F1F1F",
+		"bg":     "#FFFBEB",
+		"red":    "#CB3A2A",
+		"orange": "#A34D14",
+		"yellow": "#846E15",
+		"green":  "#14710A",
+		"cyan":   "#036A96",
+		"purple": "#644AC9",
+		"pink":   "#A3144D",
+		"white":  "#3A3A3A",
+		"gray":   "#6C664B",
+		"black":  "#DEDCCF",
+	},
+	ClideThemeHighContrast: {
+		"fg":     "",
+		"bg":     "",
+		"red":    "9",
+		"orange": "11",
+		"yellow": "11",
+		"green":  "10",
+		"cyan":   "14",
+		"purple": "13",
+		"pink":   "13",
+		"white":  "",
+		"gray":   "",
+		"black":  "",
+	},
+	ClideThemeNone: {
+		"fg":     "",
+		"bg":     "",
+		"red":    "",
+		"orange": "",
+		"yellow": "",
+		"green":  "",
+		"cyan":   "",
+		"purple": "",
+		"pink":   "",
+		"white":  "",
+		"gray":   "",
+		"black":  "",
+	},
+}
+
+// resolveTheme picks the theme to use for the configured one. NO_COLOR turns
+// colors off whatever the config says, and auto follows the background of the
+// terminal.
+func resolveTheme(theme string) string {
+	if os.Getenv("NO_COLOR") != "" {
+		return ClideThemeNone
+	}
+
+	if theme != ClideThemeAuto {
+		return theme
+	}
+
+	if lipgloss.HasDarkBackground() {
+		return ClideThemeDark
+	}
+
+	return ClideThemeLight
+}
+
+// colors is the palette of the configured theme, with any colors set in the
+// config on top.
+func (c Config) colors() map[string]string {
+	theme := resolveTheme(c.Theme)
+	colors := make(map[string]string)
+
+	for name, color := range themes[theme] {
+		colors[name] = color
+	}
+
+	if theme == ClideThemeNone {
+		return colors
+	}
+
+	for name, color := range c.Colors {
+		colors[name] = color
+	}
+
+	return colors
+}
+
+// applyTheme sets every color from the config, and rebuilds the styles.
+func applyTheme(c Config) {
+	if resolveTheme(c.Theme) == ClideThemeNone {
+		lipgloss.SetColorProfile(termenv.Ascii)
+	}
+
+	palette := palette()
+
+	for name, color := range c.colors() {
+		*palette[name] = lipgloss.Color(color)
+	}
+
+	setStyles()
+}
@@ -1,34 +1,49 @@
 package model
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os/exec"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/TeddyRandby/clide/node"
 	"github.com/TeddyRandby/clide/path"
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"golang.org/x/exp/slices"
 )
 
-var (
-	delegate = func() list.DefaultDelegate {
-		d := list.NewDefaultDelegate()
-		d.Styles.DimmedTitle.Foreground(white)
-		d.Styles.DimmedDesc.Foreground(gray)
-		d.Styles.NormalTitle.Foreground(fg)
-		d.Styles.NormalDesc.Foreground(white)
-		d.Styles.SelectedTitle.Foreground(purple)
-		d.Styles.SelectedDesc.Foreground(white)
-		d.Styles.SelectedTitle.BorderForeground(purple)
-		d.Styles.SelectedDesc.BorderForeground(purple)
-		return d
-	}()
-)
+// selectDelegate marks the options chosen for a multi select parameter.
+type selectDelegate struct {
+	list.DefaultDelegate
+	param *node.CommandNodeParameter
+}
+
+func (d selectDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
+	if i, ok := listItem.(item); ok {
+		if slices.Contains(d.param.Value, i.value) {
+			i.name = "✓ " + i.name
+		} else {
+			i.name = "  " + i.name
+		}
+
+		listItem = i
+	}
+
+	d.DefaultDelegate.Render(w, m, index, listItem)
+}
 
 func (m Clide) Backtrack() (Clide, tea.Cmd) {
+	if m.editing {
+		return m.Review()
+	}
+
 	parent := m.node.Parent
 
 	if parent == nil {
@@ -49,20 +64,53 @@ func (m Clide) Root() (Clide, tea.Cmd) {
 }
 
 func (m Clide) Error(err string) (Clide, tea.Cmd) {
-	return Clide{
-		width:  m.width,
-		height: m.height,
-		root:   m.root,
-		node:   m.node,
-		params: m.params,
-		param:  m.param,
-		args:   m.args,
-		keymap: m.keymap,
-		help:   m.help,
-		ready:  m.ready,
-		state:  ClideStateError,
-		error:  err,
-	}, nil
+	c := Clide{
+		width:    m.width,
+		height:   m.height,
+		root:     m.root,
+		node:     m.node,
+		params:   m.params,
+		param:    m.param,
+		args:     m.args,
+		headless: m.headless,
+		keymap:   m.keymap,
+		usage:    m.usage,
+		sort:     m.sort,
+		editing:  m.editing,
+		confirm:  m.confirm,
+		extra:    m.extra,
+		help:     m.help,
+		ready:    m.ready,
+		state:    ClideStateError,
+		error:    err,
+		viewport: viewport.New(m.width, m.height),
+	}
+
+	return c.resize(), nil
+}
+
+// ScriptError shows why the option or default script sibling failed, along
+// with its exit status and stderr. The script can be retried from there.
+func (m Clide) ScriptError(sibling string, err error) (Clide, tea.Cmd) {
+	c, cmd := m.Error(outputError(sibling, err))
+
+	c.sibling = sibling
+
+	var serr scriptError
+	if errors.As(err, &serr) {
+		c.detail = serr.detail()
+	}
+
+	return c.resize(), cmd
+}
+
+// Retry runs the script which failed again.
+func (m Clide) Retry() (Clide, tea.Cmd) {
+	if m.sibling == "" {
+		return m, nil
+	}
+
+	return m.Load(m.sibling)
 }
 
 func (m Clide) Command(n *node.CommandNode) (Clide, tea.Cmd) {
@@ -82,18 +130,36 @@ func (m Clide) Command(n *node.CommandNode) (Clide, tea.Cmd) {
 }
 
 func (m Clide) Done() (Clide, tea.Cmd) {
+	if m.node.Meta.Args != "" && m.extra == nil && !m.headless {
+		return m.PromptArgs()
+	}
+
+	if m.state != ClideStateReview && m.reviewing() {
+		return m.Review()
+	}
+
+	if m.headless && m.node.Meta.Dangerous && !m.confirm {
+		return m.Error(fmt.Sprintf("%s is marked as dangerous, pass %s to run it", m.node.Steps(), ClideFlagYes))
+	}
+
 	clide := Clide{
-		ready:  m.ready,
-		width:  m.width,
-		height: m.height,
-		node:   m.node,
-		root:   m.root,
-		args:   m.args,
-		keymap: m.keymap,
-		help:   m.help,
-		params: m.params,
-		param:  m.param,
-		state:  ClideStateDone,
+		ready:    m.ready,
+		width:    m.width,
+		height:   m.height,
+		node:     m.node,
+		root:     m.root,
+		args:     m.args,
+		headless: m.headless,
+		keymap:   m.keymap,
+		usage:    m.usage,
+		sort:     m.sort,
+		editing:  m.editing,
+		confirm:  m.confirm,
+		extra:    m.extra,
+		help:     m.help,
+		params:   m.params,
+		param:    m.param,
+		state:    ClideStateDone,
 	}
 
 	return clide, tea.Quit
@@ -103,8 +169,16 @@ func (m Clide) Param() node.CommandNodeParameter {
   return m.params[m.param]
 }
 
+// Set selects value for the current parameter. Multi select parameters
+// toggle value instead, while any other parameter is replaced by it.
 func (m Clide) Set(value string) {
   values := m.Param().Value
+
+  if !m.Param().Multi {
+    m.params[m.param].Value = []string{value}
+    return
+  }
+
   index := slices.Index(values, value)
 
   if index >= 0 {
@@ -117,6 +191,10 @@ func (m Clide) Set(value string) {
 func (m Clide) PromptParameter() (Clide, tea.Cmd){
 	m.param++
 
+	if m.editing {
+		return m.Review()
+	}
+
 	if len(m.params) > m.param {
 		return m.nextParameter()
 	}
@@ -124,18 +202,68 @@ func (m Clide) PromptParameter() (Clide, tea.Cmd){
   return m.Done()
 }
 
-func (m Clide) SetAndPrompParameter(value string) (Clide, tea.Cmd) {
-  m.Set(value)
+// setValues validates values and adds them to the current parameter.
+func (m Clide) setValues(values []string) error {
+  param := m.Param()
+
+  if len(values) > 1 && !param.Multi {
+    return errors.New(fmt.Sprintf("%s accepts only one value", param.Name))
+  }
+
+  for _, value := range values {
+    if err := param.Validate(value); err != nil {
+      return err
+    }
+
+    if !slices.Contains(m.Param().Value, value) {
+      m.Set(value)
+    }
+  }
+
+  return nil
+}
+
+func (m Clide) SetAndPrompParameter(values ...string) (Clide, tea.Cmd) {
+  if err := m.setValues(values); err != nil {
+    return m.Error(err.Error())
+  }
 
   return m.PromptParameter()
 }
 
+// arg returns the values given for param on the command line, either by its
+// full name or by its shortcut. The values of a multi select parameter may
+// also be separated by commas.
+func (m Clide) arg(param node.CommandNodeParameter) []string {
+	values, ok := m.args[param.Name]
+
+	if !ok {
+		values = m.args[param.Shortcut]
+	}
+
+	if !param.Multi {
+		return values
+	}
+
+	split := make([]string, 0, len(values))
+
+	for _, value := range values {
+		split = append(split, splitMulti(value)...)
+	}
+
+	return split
+}
+
 func (m Clide) nextParameter() (Clide, tea.Cmd) {
 	param := m.params[m.param]
 
-	shortcutValue := m.args[param.Shortcut]
-	if shortcutValue != "" {
-		return m.SetAndPrompParameter(shortcutValue)
+	shortcutValues := m.arg(param)
+	if len(shortcutValues) > 0 {
+		return m.SetAndPrompParameter(shortcutValues...)
+	}
+
+	if m.headless {
+		return m.resolveParameters()
 	}
 
 	switch param.Type {
@@ -148,6 +276,56 @@ func (m Clide) nextParameter() (Clide, tea.Cmd) {
 	return m.Error("Invalid parameter type")
 }
 
+// resolveParameters fills in every remaining parameter without prompting,
+// using the default script of input parameters when there is one. All of
+// the parameters which could not be resolved are reported in one error.
+func (m Clide) resolveParameters() (Clide, tea.Cmd) {
+	missing := make([]string, 0)
+
+	for ; m.param < len(m.params); m.param++ {
+		param := m.Param()
+
+		if values := m.arg(param); len(values) > 0 {
+			if err := m.setValues(values); err != nil {
+				return m.Error(err.Error())
+			}
+
+			continue
+		}
+
+		sibling := path.HasSibling(m.node.Path, param.Name)
+
+		if param.Type == node.CommandNodeParamTypeInput && sibling != "" {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout())
+			value, err := m.output(ctx, sibling)
+			cancel()
+
+			if err != nil {
+				return m.ScriptError(sibling, err)
+			}
+
+			if err := param.Validate(value); err != nil {
+				return m.Error(err.Error())
+			}
+
+			m.Set(value)
+			continue
+		}
+
+		if param.Shortcut != "" {
+			missing = append(missing, fmt.Sprintf("-%s (%s)", param.Shortcut, param.Name))
+		} else {
+			missing = append(missing, param.Name)
+		}
+	}
+
+	if len(missing) > 0 {
+		return m.Error(fmt.Sprintf("Missing parameters for %s: %s", m.node.Steps(), strings.Join(missing, ", ")))
+	}
+
+	return m.Done()
+}
+
 func (m Clide) newlist(items []list.Item) list.Model {
 
 	l := list.New(items, delegate, m.width, m.height)
@@ -169,9 +347,9 @@ func (m Clide) PromptPath(n *node.CommandNode) (Clide, tea.Cmd) {
 		return m.Error("Invalid node")
 	}
 
-	options := m.node.Children
+	options := m.sortNodes(m.node.Children)
 
-	if options == nil || len(options) == 0 {
+	if len(options) == 0 {
 		return m.Error(fmt.Sprintf("No commands found in %s", m.node.Path))
 	}
 
@@ -181,24 +359,144 @@ func (m Clide) PromptPath(n *node.CommandNode) (Clide, tea.Cmd) {
 		items[i] = list.Item(choice)
 	}
 
+	// Choosing a path starts over, so the parameters of a command chosen
+	// before, such as one left from the review, are not kept.
 	c := Clide{
-		ready:  m.ready,
-		width:  m.width,
-		height: m.height,
-		node:   m.node,
-		root:   m.root,
-		params: m.params,
-		param:  m.param,
-		args:   m.args,
-		keymap: m.keymap,
-		help:   m.help,
-		state:  ClideStatePathSelect,
-		list:   m.newlist(items),
+		ready:    m.ready,
+		width:    m.width,
+		height:   m.height,
+		node:     m.node,
+		root:     m.root,
+		args:     m.args,
+		headless: m.headless,
+		keymap:   m.keymap,
+		usage:    m.usage,
+		sort:     m.sort,
+		confirm:  m.confirm,
+		extra:    m.extra,
+		help:     m.help,
+		state:    ClideStatePathSelect,
+		list:     m.newlist(items),
 	}
 
+	c.list.SetDelegate(usageDelegate{delegate, m.usage})
+
 	return c, nil
 }
 
+// Disambiguate asks which of the children matching name was meant. Without a
+// terminal to ask in, the candidates are reported instead.
+func (m Clide) Disambiguate(name string, matches []*node.CommandNode) (Clide, tea.Cmd) {
+	if m.headless {
+		candidates := make([]string, len(matches))
+
+		for i, match := range matches {
+			candidates[i] = match.Steps()
+		}
+
+		return m.Error(fmt.Sprintf("'%s' is ambiguous, it could be any of: %s", name, strings.Join(candidates, ", ")))
+	}
+
+	c, cmd := m.PromptPath(matches[0].Parent)
+
+	if !c.Ok() {
+		return c, cmd
+	}
+
+	options := make([]node.CommandNode, len(matches))
+
+	for i, match := range matches {
+		options[i] = *match
+	}
+
+	options = c.sortNodes(options)
+	items := make([]list.Item, len(options))
+
+	for i, option := range options {
+		items[i] = list.Item(option)
+	}
+
+	c.list.SetItems(items)
+	c.ambiguous = name
+
+	return c, cmd
+}
+
+// leafItem is a command in the global search. It is matched on its steps,
+// shortcuts, aliases and description.
+type leafItem struct {
+	node node.CommandNode
+}
+
+func (i leafItem) Title() string {
+	if i.node.Shortcut != "" {
+		return fmt.Sprintf("%s (%s)", i.node.Steps(), i.node.Shortcut)
+	}
+	return i.node.Steps()
+}
+
+func (i leafItem) Description() string { return i.node.Description() }
+
+func (i leafItem) FilterValue() string {
+	shortcuts := make([]string, 0)
+
+	for n := &i.node; n.Parent != nil; n = n.Parent {
+		if n.Shortcut != "" {
+			shortcuts = append([]string{n.Shortcut}, shortcuts...)
+		} else {
+			shortcuts = append([]string{n.Name}, shortcuts...)
+		}
+	}
+
+	values := []string{i.node.Steps(), strings.Join(shortcuts, " ")}
+	values = append(values, i.node.Meta.Aliases...)
+	values = append(values, i.node.Meta.Description)
+
+	return strings.Join(values, " ")
+}
+
+// PromptSearch fuzzy searches every command in the tree, starting from the
+// root.
+func (m Clide) PromptSearch() (Clide, tea.Cmd) {
+	leaves := m.sortNodes(m.root.Leaves())
+
+	if len(leaves) == 0 {
+		return m.Error(fmt.Sprintf("No commands found in %s", m.root.Path))
+	}
+
+	items := make([]list.Item, len(leaves))
+
+	for i, leaf := range leaves {
+		items[i] = list.Item(leafItem{leaf})
+	}
+
+	c := Clide{
+		ready:    m.ready,
+		width:    m.width,
+		height:   m.height,
+		node:     m.node,
+		root:     m.root,
+		args:     m.args,
+		headless: m.headless,
+		keymap:   m.keymap,
+		usage:    m.usage,
+		sort:     m.sort,
+		editing:  m.editing,
+		confirm:  m.confirm,
+		extra:    m.extra,
+		help:     m.help,
+		state:    ClideStateSearch,
+		list:     m.newlist(items),
+	}
+
+	c.list.SetDelegate(usageDelegate{delegate, m.usage})
+
+	var cmd tea.Cmd
+	c.list, cmd = c.list.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("/")})
+
+	return c, cmd
+}
+
 type item struct {
 	name, desc, value string
 }
@@ -207,38 +505,102 @@ func (i item) Title() string       { return i.name }
 func (i item) Description() string { return i.desc }
 func (i item) FilterValue() string { return i.value }
 
-func (m Clide) PromptSelect() (Clide, tea.Cmd) {
-	name := m.params[m.param].Name
+// output runs the option or default script sibling and returns its trimmed
+// output. The script is killed if ctx is done first.
+func (m Clide) output(ctx context.Context, sibling string) (string, error) {
+	prog, argv, err := node.Interpret(sibling, "")
 
-	sibling := path.HasSibling(m.node.Path, name)
+	if err != nil {
+		return "", scriptError{-1, "", err}
+	}
 
-	if sibling == "" {
-		return m.Error(fmt.Sprintf("Invalid parameter: No %s found in %s", name, path.Parent(m.node.Path)))
+	dir, err := m.node.Workdir()
+
+	if err != nil {
+		return "", scriptError{-1, "", err}
 	}
 
-	cmd := exec.Command(sibling)
+	cmd := exec.CommandContext(ctx, prog, argv[1:]...)
 
+	cmd.Dir = dir
 	cmd.Env = m.env()
 
+	// Kill the whole process group, so that children of the script don't
+	// hold onto its output after it has been cancelled.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = time.Second
+
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+
 	output, err := cmd.Output()
 
+	if ctx.Err() != nil {
+		return "", scriptError{-1, stderr.String(), ctx.Err()}
+	}
+
 	if err != nil {
-		return m.Error(fmt.Sprintf("Could not execute command %s", sibling))
+		status := -1
+
+		var exit *exec.ExitError
+		if errors.As(err, &exit) {
+			status = exit.ExitCode()
+		}
+
+		return "", scriptError{status, stderr.String(), err}
 	}
 
-	trimmed := strings.Trim(string(output), " \n\t")
+	return strings.Trim(string(output), " \n\t"), nil
+}
 
-	options := strings.Split(trimmed, "\n")
+// scriptError is returned when an option or default script fails. Status is
+// the exit status of the script, or -1 if it didn't exit.
+type scriptError struct {
+	status int
+	stderr string
+	err    error
+}
 
-  options = slices.Compact(options)
+func (e scriptError) Error() string { return e.err.Error() }
 
-	if len(options) == 0 {
-		return m.Error(fmt.Sprintf("%s yielded no options", name))
+func (e scriptError) Unwrap() error { return e.err }
+
+func (e scriptError) detail() string {
+	detail := e.err.Error()
+
+	if e.status >= 0 {
+		detail = fmt.Sprintf("Exit status %d", e.status)
 	}
 
-	items := make([]list.Item, len(options))
+	if stderr := strings.TrimSpace(e.stderr); stderr != "" {
+		detail += "\n\n" + stderr
+	}
 
-	for i, choice := range options {
+	return detail
+}
+
+// outputError describes why sibling could not produce its output.
+func outputError(sibling string, err error) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Sprintf("Command %s timed out after %s", sibling, timeout())
+	}
+
+	return fmt.Sprintf("Could not execute command %s", sibling)
+}
+
+// parseOptions parses each line of the output of an option script as a
+// name:description:value triple.
+func parseOptions(output string) []item {
+	options := strings.Split(output, "\n")
+
+	options = slices.Compact(options)
+
+	items := make([]item, 0, len(options))
+
+	for _, choice := range options {
 		choice = strings.Trim(choice, " \n\t")
 		if choice != "" {
 			spec := strings.Split(choice, ":")
@@ -253,23 +615,167 @@ func (m Clide) PromptSelect() (Clide, tea.Cmd) {
 				value = spec[2]
 			}
 
-			items[i] = list.Item(item{spec[0], desc, value})
+			items = append(items, item{spec[0], desc, value})
 		}
 	}
 
+	return items
+}
+
+// options runs the option script sibling and parses its output.
+func (m Clide) options(sibling string) ([]item, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout())
+	defer cancel()
+
+	output, err := m.output(ctx, sibling)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return parseOptions(output), nil
+}
+
+const (
+	DefaultTimeout   = 30 * time.Second
+	DefaultCharLimit = 200
+)
+
+// timeout is how long option and default scripts may run, which can be set
+// with CLIDE_TIMEOUT, like CLIDE_TIMEOUT=1m, or in the config.
+func timeout() time.Duration {
+	t, err := time.ParseDuration(config.Timeout)
+
+	if err != nil || t <= 0 {
+		return DefaultTimeout
+	}
+
+	return t
+}
+
+type loadedMsg struct {
+	id     int
+	output string
+	err    error
+}
+
+var loads int
+
+// Load runs the option or default script sibling for the current parameter
+// in the background, showing a spinner until it finishes or is cancelled.
+func (m Clide) Load(sibling string) (Clide, tea.Cmd) {
+	loads++
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout())
+
+	c := Clide{
+		ready:    m.ready,
+		width:    m.width,
+		height:   m.height,
+		node:     m.node,
+		root:     m.root,
+		params:   m.params,
+		param:    m.param,
+		args:     m.args,
+		headless: m.headless,
+		keymap:   m.keymap,
+		usage:    m.usage,
+		sort:     m.sort,
+		editing:  m.editing,
+		confirm:  m.confirm,
+		extra:    m.extra,
+		help:     m.help,
+		state:    ClideStateLoading,
+		spinner:  newSpinner(),
+		sibling:  sibling,
+		loading:  loads,
+		cancel:   cancel,
+	}
+
+	id := c.loading
+
+	c.pending = func() tea.Msg {
+		output, err := c.output(ctx, sibling)
+		return loadedMsg{id, output, err}
+	}
+
+	return c, tea.Batch(c.spinner.Tick, c.pending)
+}
+
+// Cancel stops the script that is loading, if there is one.
+func (m Clide) Cancel() {
+	if m.cancel != nil {
+		m.cancel()
+	}
+}
+
+// Loaded finishes prompting for the current parameter with the output of
+// its script.
+func (m Clide) Loaded(msg loadedMsg) (Clide, tea.Cmd) {
+	m.Cancel()
+
+	if msg.err != nil {
+		return m.ScriptError(m.sibling, msg.err)
+	}
+
+	switch m.Param().Type {
+	case node.CommandNodeParamTypeInput:
+		return m.promptInput(msg.output)
+	case node.CommandNodeParamTypeSelect:
+		return m.promptSelect(msg.output)
+	}
+
+	return m.Error("Invalid parameter type")
+}
+
+func (m Clide) PromptSelect() (Clide, tea.Cmd) {
+	name := m.params[m.param].Name
+
+	sibling := path.HasSibling(m.node.Path, name)
+
+	if sibling == "" {
+		return m.Error(fmt.Sprintf("Invalid parameter: No %s found in %s", name, path.Parent(m.node.Path)))
+	}
+
+	return m.Load(sibling)
+}
+
+func (m Clide) promptSelect(output string) (Clide, tea.Cmd) {
+	options := parseOptions(output)
+
+	if len(options) == 0 {
+		return m.Error(fmt.Sprintf("%s yielded no options", m.Param().Name))
+	}
+
+	items := make([]list.Item, len(options))
+
+	for i, choice := range options {
+		items[i] = list.Item(choice)
+	}
+
 	c := Clide{
-		ready:  m.ready,
-		width:  m.width,
-		height: m.height,
-		node:   m.node,
-		root:   m.root,
-		params: m.params,
-		param:  m.param,
-		args:   m.args,
-		keymap: m.keymap,
-		help:   m.help,
-		state:  ClideStatePromptSelect,
-		list:   m.newlist(items),
+		ready:    m.ready,
+		width:    m.width,
+		height:   m.height,
+		node:     m.node,
+		root:     m.root,
+		params:   m.params,
+		param:    m.param,
+		args:     m.args,
+		headless: m.headless,
+		keymap:   m.keymap,
+		usage:    m.usage,
+		sort:     m.sort,
+		editing:  m.editing,
+		confirm:  m.confirm,
+		extra:    m.extra,
+		help:     m.help,
+		state:    ClideStatePromptSelect,
+		list:     m.newlist(items),
+	}
+
+	if c.Param().Multi {
+		c.list.SetDelegate(selectDelegate{delegate, &c.params[c.param]})
 	}
 
 	return c, nil
@@ -280,22 +786,26 @@ func (m Clide) PromptInput() (Clide, tea.Cmd) {
 
 	sibling := path.HasSibling(m.node.Path, name)
 
-	defaultValue := ""
-
 	if sibling != "" {
-		cmd := exec.Command(sibling)
+		return m.Load(sibling)
+	}
 
-		cmd.Env = m.env()
+	return m.promptInput("")
+}
 
-		output, err := cmd.Output()
+// PromptArgs asks for the arguments passed through to a command which
+// declares that it accepts them.
+func (m Clide) PromptArgs() (Clide, tea.Cmd) {
+	c, cmd := m.promptInput("")
 
-		if err != nil {
-			return m.Error(fmt.Sprintf("Could not execute command %s", sibling))
-		}
+	c.state = ClideStatePromptArgs
+	c.textarea.Placeholder = m.node.Meta.Args
+	c.textarea.CharLimit = 0
 
-		defaultValue = strings.Trim(string(output), " \n\t")
-	}
+	return c, cmd
+}
 
+func (m Clide) promptInput(defaultValue string) (Clide, tea.Cmd) {
 	c := Clide{
 		ready:    m.ready,
 		width:    m.width,
@@ -305,14 +815,23 @@ func (m Clide) PromptInput() (Clide, tea.Cmd) {
 		params:   m.params,
 		param:    m.param,
 		args:     m.args,
+		headless: m.headless,
 		keymap:   m.keymap,
+		usage:    m.usage,
+		sort:     m.sort,
+		editing:  m.editing,
+		confirm:  m.confirm,
+		extra:    m.extra,
 		help:     m.help,
 		state:    ClideStatePromptInput,
 		textarea: textarea.New(),
 	}
 
 	c.textarea.SetValue(defaultValue)
-	c.textarea.CharLimit = 200
+	if c.param < len(c.params) {
+		c.textarea.Placeholder = c.Param().Hint()
+	}
+	c.textarea.CharLimit = config.CharLimit
 	c.textarea.ShowLineNumbers = false
 	c.textarea.FocusedStyle.Prompt.Margin(0, 0, 0, 1)
 	c.textarea.FocusedStyle.Prompt.Foreground(white)
@@ -2,21 +2,20 @@ package model
 
 import (
 	"fmt"
-	"strings"
+	"slices"
 
 	"github.com/TeddyRandby/clide/node"
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 func (m Clide) Index(name string) int {
-	for i, child := range m.node.Children {
-		if child.Shortcut != "" && child.Shortcut == name {
-			return i
-		}
-
-		if strings.HasPrefix(child.Name, name) {
-			return i
+	if child := m.node.Match(name); child != nil {
+		for i := range m.node.Children {
+			if m.node.Children[i].Path == child.Path {
+				return i
+			}
 		}
 	}
 
@@ -24,16 +23,29 @@ func (m Clide) Index(name string) int {
 }
 
 func (m Clide) SelectPath(name string) (Clide, tea.Cmd) {
-	for _, child := range m.node.Children {
+	matches := m.node.Resolve(name)
 
-		if strings.HasPrefix(child.Name, name) || child.Shortcut == name {
-			switch child.Type {
-			case node.NodeTypeCommand:
-				return m.Command(&child)
+	if len(matches) > 1 {
+		return m.Disambiguate(name, matches)
+	}
 
-			case node.NodeTypeModule:
-				return m.PromptPath(&child)
-			}
+	var child *node.CommandNode
+
+	if len(matches) == 1 {
+		child = matches[0]
+	}
+
+	if child != nil && child.Err != nil {
+		return m.Error(fmt.Sprintf("%s can't be used: %s", child.Path, child.Err))
+	}
+
+	if child != nil {
+		switch child.Type {
+		case node.NodeTypeCommand:
+			return m.Command(child)
+
+		case node.NodeTypeModule:
+			return m.PromptPath(child)
 		}
 	}
 
@@ -46,6 +58,12 @@ func (m Clide) updateInput(msg tea.Msg) (Clide, tea.Cmd) {
 		switch {
 		case key.Matches(msg, m.keymap.Next):
 			value := m.textarea.Value()
+
+			if err := m.Param().Validate(value); err != nil {
+				m.invalid = err.Error()
+				return m, nil
+			}
+
 			return m.SetAndPrompParameter(value)
 		case key.Matches(msg, m.keymap.Prev):
 			return m.Backtrack()
@@ -56,6 +74,43 @@ func (m Clide) updateInput(msg tea.Msg) (Clide, tea.Cmd) {
 		}
 	}
 
+	if _, ok := msg.(tea.KeyMsg); ok {
+		m.invalid = ""
+	}
+
+	var cmd tea.Cmd
+	m.textarea, cmd = m.textarea.Update(msg)
+
+	return m, cmd
+}
+
+func (m Clide) updateArgs(msg tea.Msg) (Clide, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, m.keymap.Next):
+			args, err := splitArgs(m.textarea.Value())
+
+			if err != nil {
+				m.invalid = err.Error()
+				return m, nil
+			}
+
+			m.extra = args
+			return m.Done()
+		case key.Matches(msg, m.keymap.Prev):
+			return m.Backtrack()
+		case key.Matches(msg, m.keymap.Quit):
+			return m, tea.Quit
+		case key.Matches(msg, m.keymap.Root):
+			return m.Root()
+		}
+	}
+
+	if _, ok := msg.(tea.KeyMsg); ok {
+		m.invalid = ""
+	}
+
 	var cmd tea.Cmd
 	m.textarea, cmd = m.textarea.Update(msg)
 
@@ -80,6 +135,13 @@ func (m Clide) updatePathSelect(msg tea.Msg) (Clide, tea.Cmd) {
 		case key.Matches(msg, m.keymap.Root):
 			return m.Root()
 
+		case key.Matches(msg, m.keymap.Sort):
+			if !m.list.SettingFilter() {
+				return m.ToggleSort().promptPathAt(m.list.SelectedItem())
+			}
+		case key.Matches(msg, m.keymap.SearchAll):
+			return m.PromptSearch()
+
 		case key.Matches(msg, m.keymap.VimNext):
 			fallthrough
 		case key.Matches(msg, m.keymap.Next):
@@ -101,6 +163,34 @@ func (m Clide) updatePathSelect(msg tea.Msg) (Clide, tea.Cmd) {
 	return m, cmd
 }
 
+func (m Clide) updateSearch(msg tea.Msg) (Clide, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, m.keymap.Quit):
+			return m, tea.Quit
+
+		case key.Matches(msg, m.keymap.Root):
+			return m.Root()
+
+		case key.Matches(msg, m.keymap.Next):
+			if leaf, ok := m.list.SelectedItem().(leafItem); ok {
+				return m.Command(&leaf.node)
+			}
+
+		case key.Matches(msg, m.keymap.Prev):
+			if !m.list.SettingFilter() {
+				return m.PromptPath(m.node)
+			}
+		}
+	}
+
+	var cmd tea.Cmd
+	m.list, cmd = m.list.Update(msg)
+
+	return m, cmd
+}
+
 func (m Clide) updateError(msg tea.Msg) (Clide, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
@@ -119,10 +209,48 @@ func (m Clide) updateError(msg tea.Msg) (Clide, tea.Cmd) {
 			fallthrough
 		case key.Matches(msg, m.keymap.Prev):
 			return m.Backtrack()
+
+		case key.Matches(msg, m.keymap.Retry):
+			return m.Retry()
 		}
 	}
 
-	return m, nil
+	var cmd tea.Cmd
+	m.viewport, cmd = m.viewport.Update(msg)
+
+	return m, cmd
+}
+
+func (m Clide) updateReview(msg tea.Msg) (Clide, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, m.keymap.VimQuit):
+			fallthrough
+		case key.Matches(msg, m.keymap.Quit):
+			return m, tea.Quit
+
+		case key.Matches(msg, m.keymap.Confirm):
+			return m.Done()
+
+		case key.Matches(msg, m.keymap.Edit):
+			return m.Edit(slices.Index(m.keymap.Edit.Keys(), msg.String()))
+
+		case key.Matches(msg, m.keymap.Copy):
+			return m.Copy()
+
+		case key.Matches(msg, m.keymap.Root):
+			return m.Root()
+
+		case key.Matches(msg, m.keymap.Prev):
+			return m.Backtrack()
+		}
+	}
+
+	var cmd tea.Cmd
+	m.viewport, cmd = m.viewport.Update(msg)
+
+	return m, cmd
 }
 
 func (m Clide) updateSelect(msg tea.Msg) (Clide, tea.Cmd) {
@@ -146,10 +274,14 @@ func (m Clide) updateSelect(msg tea.Msg) (Clide, tea.Cmd) {
 		case key.Matches(msg, m.keymap.VimNext):
 			fallthrough
 		case key.Matches(msg, m.keymap.Next):
-			if !m.list.SettingFilter() && m.Param().HasValue() {
+			if !m.list.SettingFilter() && m.Param().Multi && m.Param().HasValue() {
         return m.PromptParameter()
 			}
 
+			if !m.list.SettingFilter() && m.list.SelectedItem() != nil {
+				return m.SetAndPrompParameter(m.list.SelectedItem().FilterValue())
+			}
+
 		case key.Matches(msg, m.keymap.VimPrev):
 			if !m.list.SettingFilter() {
 				return m.Backtrack()
@@ -158,7 +290,13 @@ func (m Clide) updateSelect(msg tea.Msg) (Clide, tea.Cmd) {
 			return m.Backtrack()
 
     case key.Matches(msg, m.keymap.Select):
-      m.Set(m.list.SelectedItem().FilterValue())
+      if !m.list.SettingFilter() && m.list.SelectedItem() != nil {
+        if !m.Param().Multi {
+          return m.SetAndPrompParameter(m.list.SelectedItem().FilterValue())
+        }
+
+        m.Set(m.list.SelectedItem().FilterValue())
+      }
 		}
 	}
 
@@ -169,12 +307,54 @@ func (m Clide) updateSelect(msg tea.Msg) (Clide, tea.Cmd) {
 
 }
 
+func (m Clide) updateLoading(msg tea.Msg) (Clide, tea.Cmd) {
+	switch msg := msg.(type) {
+	case loadedMsg:
+		if msg.id == m.loading {
+			return m.Loaded(msg)
+		}
+
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, m.keymap.VimQuit):
+			fallthrough
+		case key.Matches(msg, m.keymap.Quit):
+			m.Cancel()
+			return m, tea.Quit
+
+		case key.Matches(msg, m.keymap.VimRoot):
+			fallthrough
+		case key.Matches(msg, m.keymap.Root):
+			m.Cancel()
+			return m.Root()
+
+		case key.Matches(msg, m.keymap.VimPrev):
+			fallthrough
+		case key.Matches(msg, m.keymap.Prev):
+			m.Cancel()
+			return m.Backtrack()
+		}
+	}
+
+	return m, nil
+}
+
 func (m Clide) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
 		m.ready = true
+		m = m.resize()
+	}
+
+	if _, ok := msg.(spinner.TickMsg); ok && m.state != ClideStateLoading {
+		return m, nil
 	}
 
 	switch m.state {
@@ -185,11 +365,26 @@ func (m Clide) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case ClideStatePathSelect:
 		return m.updatePathSelect(msg)
 
+	case ClideStateSearch:
+		return m.updateSearch(msg)
+
 	case ClideStatePromptSelect:
 		return m.updateSelect(msg)
 
 	case ClideStatePromptInput:
 		return m.updateInput(msg)
+
+	case ClideStatePromptArgs:
+		return m.updateArgs(msg)
+
+	case ClideStateLoading:
+		return m.updateLoading(msg)
+
+	case ClideStateReview:
+		return m.updateReview(msg)
+
+	case ClideStateDone:
+		return m, nil
 	}
 
 	panic("unreachable")
@@ -0,0 +1,171 @@
+package model
+
+import (
+	"io"
+	"strings"
+	"time"
+
+	"github.com/TeddyRandby/clide/node"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	ClideSortFrecency     = "frecency"
+	ClideSortAlphabetical = "alphabetical"
+)
+
+// RecentWindow is how long a command is marked as recently used after it is
+// run.
+const RecentWindow = 7 * 24 * time.Hour
+
+// RecentMarker is shown before recently used commands and modules.
+const RecentMarker = "• "
+
+// usage is how often and how recently a node was chosen, derived from the
+// history of the project.
+type usage struct {
+	last  time.Time
+	score int
+}
+
+// usages are keyed by the steps of each node.
+type usages map[string]usage
+
+// frecencyWeight scores a single use of a node by its age, so that a few
+// recent uses outrank many old ones.
+func frecencyWeight(age time.Duration) int {
+	day := 24 * time.Hour
+
+	switch {
+	case age < 4*day:
+		return 100
+	case age < 14*day:
+		return 70
+	case age < 31*day:
+		return 50
+	case age < 90*day:
+		return 30
+	default:
+		return 10
+	}
+}
+
+// frecency tallies the usage of every node in the history, keyed by steps.
+// Running a command counts as choosing each of the modules leading to it.
+func (m Clide) frecency() usages {
+	tally := make(usages)
+
+	entries, err := m.history()
+
+	if err != nil {
+		return tally
+	}
+
+	now := time.Now()
+
+	for _, entry := range entries {
+		for i := range entry.Steps {
+			key := strings.Join(entry.Steps[:i+1], " ")
+
+			u := tally[key]
+			u.score += frecencyWeight(now.Sub(entry.Time))
+
+			if entry.Time.After(u.last) {
+				u.last = entry.Time
+			}
+
+			tally[key] = u
+		}
+	}
+
+	return tally
+}
+
+// recent reports whether the node with the given steps was used within the
+// RecentWindow.
+func (u usages) recent(steps string) bool {
+	use, ok := u[steps]
+
+	return ok && time.Since(use.last) < RecentWindow
+}
+
+// sortNodes orders nodes by frecency, most used first, or by name.
+func (m Clide) sortNodes(nodes []node.CommandNode) []node.CommandNode {
+	sorted := slices.Clone(nodes)
+
+	if m.sort == ClideSortAlphabetical {
+		slices.SortStableFunc(sorted, func(a, b node.CommandNode) bool {
+			return a.Name < b.Name
+		})
+
+		return sorted
+	}
+
+	slices.SortStableFunc(sorted, func(a, b node.CommandNode) bool {
+		return m.usage[a.Steps()].score > m.usage[b.Steps()].score
+	})
+
+	return sorted
+}
+
+// ToggleSort switches the menu between frecency and alphabetical order.
+func (m Clide) ToggleSort() Clide {
+	if m.sort == ClideSortAlphabetical {
+		m.sort = ClideSortFrecency
+	} else {
+		m.sort = ClideSortAlphabetical
+	}
+
+	return m
+}
+
+// promptPathAt prompts for the children of the current node again, keeping
+// the cursor on selected.
+func (m Clide) promptPathAt(selected list.Item) (Clide, tea.Cmd) {
+	c, cmd := m.PromptPath(m.node)
+
+	for i, item := range c.list.Items() {
+		if selected != nil && item.FilterValue() == selected.FilterValue() {
+			c.list.Select(i)
+		}
+	}
+
+	return c, cmd
+}
+
+// recentItem marks an item as recently used.
+type recentItem struct {
+	list.DefaultItem
+}
+
+func (i recentItem) Title() string { return RecentMarker + i.DefaultItem.Title() }
+
+// usageDelegate marks the commands and modules which were recently used.
+type usageDelegate struct {
+	list.DefaultDelegate
+	usage usages
+}
+
+func (d usageDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
+	var n node.CommandNode
+
+	switch i := listItem.(type) {
+	case node.CommandNode:
+		n = i
+	case leafItem:
+		n = i.node
+	}
+
+	// Broken is checked before wrapping, as recent items are still broken.
+	if n.Err != nil {
+		d.DefaultDelegate.Styles = brokenStyles
+	}
+
+	if i, ok := listItem.(list.DefaultItem); ok && d.usage.recent(n.Steps()) {
+		listItem = recentItem{i}
+	}
+
+	d.DefaultDelegate.Render(w, m, index, listItem)
+}
@@ -6,6 +6,9 @@ import (
 	"strings"
 
 	"github.com/TeddyRandby/clide/node"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/lipgloss"
 )
 
@@ -41,53 +44,143 @@ var (
 var clide_header = "c l i d e"
 
 var (
-	titleStyle = func() lipgloss.Style {
-		return lipgloss.
-			NewStyle().
-			Foreground(purple).
-			Margin(0, 0, 0, 2)
-	}()
-	promptStyle = func() lipgloss.Style {
-		return lipgloss.
-			NewStyle().
-			Foreground(orange).
-			Margin(1, 1, 0).
-			Padding(0, 1)
-	}()
-	stepStyle = func() lipgloss.Style {
-		return lipgloss.
-			NewStyle().
-			ColorWhitespace(false).
-			Foreground(white).
-			Margin(0, 1).
-			Padding(0, 1)
-	}()
-	sepStyle = func() lipgloss.Style {
-		return lipgloss.
-			NewStyle().
-			Foreground(purple)
-	}()
-	helpStyle = func() lipgloss.Style {
-		return lipgloss.
-			NewStyle().
-			Foreground(gray).
-			Padding(0, 1)
-	}()
-	errorStyle = func() lipgloss.Style {
-		return lipgloss.
-			NewStyle().
-			Foreground(red).
-			Padding(1, 1).
-			Margin(0, 1)
-	}()
-	spinnerStyle = func() lipgloss.Style {
-		return lipgloss.
-			NewStyle().
-			Foreground(red).
-			Padding(0, 1)
-	}()
+	titleStyle    lipgloss.Style
+	promptStyle   lipgloss.Style
+	stepStyle     lipgloss.Style
+	sepStyle      lipgloss.Style
+	helpStyle     lipgloss.Style
+	errorStyle    lipgloss.Style
+	detailStyle   lipgloss.Style
+	invalidStyle  lipgloss.Style
+	spinnerStyle  lipgloss.Style
+	loadingStyle  lipgloss.Style
+	noticeStyle   lipgloss.Style
+	dangerStyle   lipgloss.Style
+	reviewStyle   lipgloss.Style
+	reviewLabel   lipgloss.Style
+	previewStyle  lipgloss.Style
+	previewTitle  lipgloss.Style
+	previewLabel  lipgloss.Style
+	previewText   lipgloss.Style
+	shebangStyle  lipgloss.Style
+	commentStyle  lipgloss.Style
+	stringStyle   lipgloss.Style
+	variableStyle lipgloss.Style
+	keywordStyle  lipgloss.Style
+	codeStyle     lipgloss.Style
+	delegate      list.DefaultDelegate
+	brokenStyles  list.DefaultItemStyles
 )
 
+func init() {
+	setStyles()
+}
+
+// setStyles builds every style from the colors above, so that they can be
+// changed at runtime.
+func setStyles() {
+	titleStyle = lipgloss.
+		NewStyle().
+		Foreground(purple).
+		Margin(0, 0, 0, 2)
+	promptStyle = lipgloss.
+		NewStyle().
+		Foreground(orange).
+		Margin(1, 1, 0).
+		Padding(0, 1)
+	stepStyle = lipgloss.
+		NewStyle().
+		ColorWhitespace(false).
+		Foreground(white).
+		Margin(0, 1).
+		Padding(0, 1)
+	sepStyle = lipgloss.
+		NewStyle().
+		Foreground(purple)
+	helpStyle = lipgloss.
+		NewStyle().
+		Foreground(gray).
+		Padding(0, 1)
+	errorStyle = lipgloss.
+		NewStyle().
+		Foreground(red).
+		Padding(1, 1).
+		Margin(0, 1)
+	detailStyle = lipgloss.
+		NewStyle().
+		Foreground(white).
+		Padding(0, 1, 1).
+		Margin(0, 1)
+	invalidStyle = lipgloss.
+		NewStyle().
+		Foreground(red).
+		Padding(0, 1)
+	spinnerStyle = lipgloss.
+		NewStyle().
+		Foreground(red)
+	loadingStyle = lipgloss.
+		NewStyle().
+		Foreground(gray).
+		Padding(1, 1).
+		Margin(0, 1)
+	noticeStyle = lipgloss.
+		NewStyle().
+		Foreground(green).
+		Padding(0, 1)
+	dangerStyle = lipgloss.
+		NewStyle().
+		Foreground(red)
+	reviewStyle = lipgloss.
+		NewStyle().
+		Foreground(white).
+		Padding(1, 1).
+		Margin(0, 1)
+	reviewLabel = lipgloss.
+		NewStyle().
+		Foreground(purple)
+	previewStyle = lipgloss.
+		NewStyle().
+		Border(lipgloss.NormalBorder(), false, false, false, true).
+		BorderForeground(gray).
+		Padding(0, 1)
+	previewTitle = lipgloss.NewStyle().Foreground(purple)
+	previewLabel = lipgloss.NewStyle().Foreground(gray)
+	previewText = lipgloss.NewStyle().Foreground(white)
+
+	shebangStyle = lipgloss.NewStyle().Foreground(purple)
+	commentStyle = lipgloss.NewStyle().Foreground(gray)
+	stringStyle = lipgloss.NewStyle().Foreground(yellow)
+	variableStyle = lipgloss.NewStyle().Foreground(cyan)
+	keywordStyle = lipgloss.NewStyle().Foreground(pink)
+	codeStyle = lipgloss.NewStyle().Foreground(fg)
+
+	delegate = list.NewDefaultDelegate()
+	delegate.Styles.DimmedTitle.Foreground(white)
+	delegate.Styles.DimmedDesc.Foreground(gray)
+	delegate.Styles.NormalTitle.Foreground(fg)
+	delegate.Styles.NormalDesc.Foreground(white)
+	delegate.Styles.SelectedTitle.Foreground(purple)
+	delegate.Styles.SelectedDesc.Foreground(white)
+	delegate.Styles.SelectedTitle.BorderForeground(purple)
+	delegate.Styles.SelectedDesc.BorderForeground(purple)
+
+	// Broken commands and modules are greyed out, whether selected or not.
+	brokenStyles = list.NewDefaultItemStyles()
+	brokenStyles.NormalTitle.Foreground(gray)
+	brokenStyles.NormalDesc.Foreground(gray)
+	brokenStyles.SelectedTitle.Foreground(gray).BorderForeground(gray)
+	brokenStyles.SelectedDesc.Foreground(gray).BorderForeground(gray)
+	brokenStyles.DimmedTitle.Foreground(gray)
+	brokenStyles.DimmedDesc.Foreground(gray)
+}
+
+func newSpinner() spinner.Model {
+	return spinner.New(
+		spinner.WithSpinner(spinner.Dot),
+		spinner.WithStyle(spinnerStyle),
+	)
+}
+
 func (m Clide) headerView() string {
 	var steps []string
 
@@ -128,6 +221,14 @@ func (m Clide) promptView() string {
 }
 
 func (m Clide) preview() string {
+	if m.state == ClideStatePromptArgs {
+		if m.textarea.Value() == "" {
+			return "args"
+		}
+
+		return m.textarea.Value()
+	}
+
 	if len(m.params) == 0 {
 		return ""
 	}
@@ -151,9 +252,70 @@ func (m Clide) preview() string {
 func (m Clide) helpView() string {
 	m.help.Styles.ShortKey.Foreground(gray)
 	m.help.Styles.ShortDesc.Foreground(gray)
+
+	if m.state == ClideStateError && m.sibling != "" && !m.help.ShowAll {
+		return helpStyle.Render(m.help.ShortHelpView(append([]key.Binding{m.keymap.Retry}, m.keymap.ShortHelp()...)))
+	}
+
+	if m.state == ClideStateReview {
+		return helpStyle.Render(m.help.ShortHelpView([]key.Binding{m.keymap.Confirm, m.keymap.Edit, m.keymap.Copy, m.keymap.Prev, m.keymap.Quit}))
+	}
+
 	return helpStyle.Render(m.help.View(m.keymap))
 }
 
+// errorContent renders the error, and the details of the script that failed.
+func (m Clide) errorContent() string {
+	width := max(m.width-errorStyle.GetHorizontalFrameSize(), 0)
+
+	content := errorStyle.
+		Copy().
+		Width(width).
+		Render(fmt.Sprintf("Clide Error: %s.", m.error))
+
+	if m.detail == "" {
+		return content
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		content,
+		detailStyle.Copy().Width(width).Render(m.detail),
+	)
+}
+
+// resize fits the scrolling error and review views to the window.
+func (m Clide) resize() Clide {
+	if m.state != ClideStateError && m.state != ClideStateReview {
+		return m
+	}
+
+	m.help.Width = m.width
+
+	verticalSpace := lipgloss.Height(m.headerView()) + lipgloss.Height(m.helpView()) + 1
+
+	m.viewport.Width = m.width
+	m.viewport.Height = max(m.height-verticalSpace, 0)
+
+	if m.state == ClideStateReview {
+		m.viewport.Height = max(m.viewport.Height-1, 0)
+		m.viewport.SetContent(m.reviewContent())
+	} else {
+		m.viewport.SetContent(m.errorContent())
+	}
+
+	return m
+}
+
+// problemsView warns about trees which could not be merged into the root,
+// like commands of the user which are hidden by the project's own.
+func (m Clide) problemsView() string {
+	if len(m.root.Problems) == 1 {
+		return m.root.Problems[0].String()
+	}
+
+	return fmt.Sprintf("%s (and %d more, see clide @doctor)", m.root.Problems[0], len(m.root.Problems)-1)
+}
+
 func (m Clide) View() string {
 	m.help.Width = m.width
 
@@ -169,13 +331,24 @@ func (m Clide) View() string {
 	case ClideStateDone:
 		return ""
 
+	case ClideStateSearch:
+		fallthrough
 	case ClideStatePathSelect:
-		m.list.SetSize(m.width, m.height-verticalSpace)
+		footer := []string{helpView}
+
+		if m.node == m.root && len(m.root.Problems) > 0 {
+			footer = []string{invalidStyle.Render(m.problemsView()), helpView}
+		}
+
+		if m.ambiguous != "" {
+			footer = []string{noticeStyle.Render(fmt.Sprintf("'%s' matches several commands, pick one", m.ambiguous)), helpView}
+		}
 
 		return lipgloss.JoinVertical(lipgloss.Left,
-			headerView,
-			m.list.View(),
-			helpView,
+			append([]string{
+				headerView,
+				m.splitView(m.height - verticalSpace - len(footer) + 1),
+			}, footer...)...,
 		)
 
 	case ClideStatePromptSelect:
@@ -186,28 +359,51 @@ func (m Clide) View() string {
 			helpView,
 		)
 
+	case ClideStatePromptArgs:
+		fallthrough
 	case ClideStatePromptInput:
 		m.textarea.SetWidth(m.width)
 
-		spaceRemaining := m.height - verticalSpace
+		footer := []string{helpView}
+
+		if m.invalid != "" {
+			footer = []string{invalidStyle.Render(m.invalid), helpView}
+		}
+
+		spaceRemaining := m.height - verticalSpace - len(footer) + 1
 
 		m.textarea.SetHeight(spaceRemaining)
 
 		return lipgloss.JoinVertical(lipgloss.Left,
-			lipgloss.JoinHorizontal(lipgloss.Right, headerView, m.promptView()),
-			m.textarea.View(),
-			helpView,
+			append([]string{
+				lipgloss.JoinHorizontal(lipgloss.Right, headerView, m.promptView()),
+				m.textarea.View(),
+			}, footer...)...,
 		)
 
-	case ClideStateError:
-		content := errorStyle.
+	case ClideStateLoading:
+		content := loadingStyle.
 			Copy().
 			Height(m.height - verticalSpace).
-			Render(fmt.Sprintf("Clide Error: %s.", m.error))
+			Render(fmt.Sprintf("%s Loading %s", m.spinner.View(), m.Param().Name))
 
 		return lipgloss.JoinVertical(lipgloss.Left,
-			headerView,
+			lipgloss.JoinHorizontal(lipgloss.Right, headerView, m.promptView()),
 			content,
+			helpView,
+		)
+
+	case ClideStateReview:
+		return lipgloss.JoinVertical(lipgloss.Left,
+			headerView,
+			m.viewport.View(),
+			noticeStyle.Render(m.notice),
+			helpView)
+
+	case ClideStateError:
+		return lipgloss.JoinVertical(lipgloss.Left,
+			headerView,
+			m.viewport.View(),
 			helpView)
 	}
 
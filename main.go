@@ -3,9 +3,12 @@ package main
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 
 	clide "github.com/TeddyRandby/clide/app"
+	"github.com/mattn/go-isatty"
 )
 
 func is_builtin(args []string) bool {
@@ -16,22 +19,100 @@ func get_builtin(args []string) string {
   return args[0][1:]
 }
 
+// take_root removes the --root flag from args, returning its value.
+func take_root(args []string) (string, []string, error) {
+	for i, arg := range args {
+		if arg == clide.ClideArgsSeparator {
+			break
+		}
+
+		if value, found := strings.CutPrefix(arg, clide.ClideFlagRoot+"="); found {
+			return value, slices.Delete(args, i, i+1), nil
+		}
+
+		if arg == clide.ClideFlagRoot {
+			if i+1 >= len(args) {
+				return "", args, fmt.Errorf("Flag %s needs a directory", clide.ClideFlagRoot)
+			}
+
+			value := args[i+1]
+
+			return value, slices.Delete(args, i, i+2), nil
+		}
+	}
+
+	return "", args, nil
+}
+
+func is_headless() bool {
+	return !isatty.IsTerminal(os.Stdin.Fd()) || !isatty.IsTerminal(os.Stdout.Fd())
+}
+
 func main() {
 	args := os.Args[1:]
 
-	params := make(map[string]string)
-	c := clide.New(params)
+	headless := is_headless()
 
-	if !c.Ok() {
-		c.Run()
+	root, args, err := take_root(args)
+
+	if err != nil {
+		m, _ := clide.New(nil).Error(err.Error())
+
+		if headless {
+			m = m.Headless()
+		}
+
+		m.Run()
 		return
 	}
 
+	// The flag is passed on through the environment, so that commands which
+	// run clide again find the same project.
+	if root != "" {
+		if root, err = filepath.Abs(root); err == nil {
+			os.Setenv("CLIDE_ROOT", root)
+		}
+	}
+
+	if is_builtin(args) && get_builtin(args) == clide.ClideBuiltinRun {
+		headless = true
+		args = args[1:]
+	}
+
+	var extra []string
+
+	if i := slices.Index(args, clide.ClideArgsSeparator); i >= 0 {
+		extra = args[i+1:]
+		args = args[:i]
+	}
+
+	confirmed := slices.Contains(args, clide.ClideFlagYes)
+
+	if confirmed {
+		args = slices.DeleteFunc(args, func(arg string) bool { return arg == clide.ClideFlagYes })
+	}
+
+	params := make(map[string][]string)
+	c := clide.New(params)
+
+	if headless {
+		c = c.Headless()
+	}
+
+	if confirmed {
+		c = c.Confirmed()
+	}
+
 	if is_builtin(args) {
-    c.Builtin(get_builtin(args))
+    c.Builtin(get_builtin(args), args[1:])
     return
 	}
 
+	if !c.Ok() {
+		c.Run()
+		return
+	}
+
 	steps := make([]string, 0)
 
 	for _, arg := range args {
@@ -44,13 +125,21 @@ func main() {
 				return
 			}
 
-      params[name[1:]] = value
+      params[name[1:]] = append(params[name[1:]], value)
 		} else {
 			steps = append(steps, strings.ToLower(arg))
 		}
 	}
 
-	c = clide.New(params)
+	c = clide.New(params).Passthrough(extra)
+
+	if headless {
+		c = c.Headless()
+	}
+
+	if confirmed {
+		c = c.Confirmed()
+	}
 
 	if !c.Ok() {
 		c.Run()
@@ -59,6 +148,10 @@ func main() {
 
 	for _, step := range steps {
 		c, _ = c.SelectPath(step)
+
+		if !c.Ok() || c.Ambiguous() {
+			break
+		}
 	}
 
 	c.Run()
@@ -0,0 +1,207 @@
+package node
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/TeddyRandby/clide/path"
+)
+
+// Problem is something wrong with a file in a command tree.
+type Problem struct {
+	Path    string
+	Message string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %s", p.Path, p.Message)
+}
+
+// ImportantEnv are the environment variables which parameters should not
+// shadow, as commands and the tools they run rely on them. Names are compared
+// regardless of case, since zsh ties path to PATH.
+var ImportantEnv = []string{
+	"PATH", "HOME", "USER", "SHELL", "PWD", "OLDPWD", "TERM", "LANG", "TMPDIR",
+	"IFS", "EDITOR", "LD_LIBRARY_PATH", "LD_PRELOAD",
+	"CLIDE_PATH", "CLIDE_ROOT", "CLIDE_HOME",
+}
+
+type doctor struct {
+	root     string
+	problems []Problem
+}
+
+// sibling is a command or module as its siblings see it, for finding names
+// and shortcuts which collide.
+type sibling struct {
+	path     string
+	name     string
+	shortcut string
+	aliases  []string
+}
+
+// Doctor checks the tree in root without loading it, so that every problem is
+// found, even those which stop the tree from loading.
+func Doctor(root string) []Problem {
+	d := doctor{root: root}
+
+	d.module(root, nil)
+
+	return d.problems
+}
+
+func (d *doctor) report(pth string, format string, args ...any) {
+	d.problems = append(d.problems, Problem{Path: pth, Message: fmt.Sprintf(format, args...)})
+}
+
+// module checks dir, whose commands take the select parameters in selects.
+func (d *doctor) module(dir string, selects []string) {
+	siblings := d.entries(dir, selects)
+
+	for i, a := range siblings {
+		for _, b := range siblings[:i] {
+			if a.name == b.name {
+				d.report(a.path, "Duplicate name %s, also used by %s", a.name, b.path)
+			}
+
+			if word := collision(a, b); word != "" {
+				d.report(a.path, "Shortcut %s collides with %s", word, b.path)
+			} else if word := collision(b, a); word != "" {
+				d.report(b.path, "Shortcut %s collides with %s", word, a.path)
+			}
+		}
+	}
+}
+
+// collision is the shortcut or alias of a which also selects b, if any.
+func collision(a, b sibling) string {
+	for _, word := range append([]string{a.shortcut}, a.aliases...) {
+		if word == "" || word == a.name {
+			continue
+		}
+
+		if word == b.name || word == b.shortcut || slices.Contains(b.aliases, word) {
+			return word
+		}
+	}
+
+	return ""
+}
+
+// entries checks the children of dir, and returns those which are siblings in
+// the tree. The contents of parameter directories are siblings of the
+// parameter itself.
+func (d *doctor) entries(dir string, selects []string) []sibling {
+	childs, err := path.Children(dir)
+
+	if err != nil {
+		d.report(dir, "%s", err)
+		return nil
+	}
+
+	siblings := make([]sibling, 0)
+
+	for _, child := range childs {
+		base := filepath.Base(child)
+
+		if strings.HasPrefix(base, ".") || (dir == d.root && base == path.ConfigFile) {
+			continue
+		}
+
+		if _, err := os.Stat(child); err != nil {
+			if target, err := os.Readlink(child); err == nil {
+				d.report(child, "Broken symlink to %s", target)
+			} else {
+				d.report(child, "%s", err)
+			}
+			continue
+		}
+
+		if path.IsParameter(child) {
+			if path.IsLeaf(child) {
+				d.report(child, "A leaf cannot require a parameter")
+				continue
+			}
+
+			name, _ := parameterNameAndShortcut(base)
+
+			for _, env := range ImportantEnv {
+				if strings.EqualFold(name, env) {
+					d.report(child, "Parameter %s shadows the %s environment variable", name, env)
+				}
+			}
+
+			if path.IsSelectParameter(child) {
+				siblings = append(siblings, d.entries(child, append(slices.Clip(selects), name))...)
+			} else {
+				siblings = append(siblings, d.entries(child, selects)...)
+			}
+			continue
+		}
+
+		name, shortcut := moduleNameAndShortcut(base)
+
+		if path.IsModule(child) {
+			meta := parseMeta(filepath.Join(child, CommandNodeMetaFile))
+			siblings = append(siblings, sibling{child, name, shortcut, meta.Aliases})
+			d.module(child, selects)
+			continue
+		}
+
+		// Files without an extension are option and default scripts.
+		if !strings.Contains(base, ".") {
+			continue
+		}
+
+		meta := parseMeta(child)
+		siblings = append(siblings, sibling{child, name, shortcut, meta.Aliases})
+
+		d.script(child, meta)
+
+		for _, param := range selects {
+			if path.HasSibling(child, param) == "" {
+				d.report(child, "Select parameter %s has no option script, expected %s",
+					param, filepath.Join(filepath.Dir(child), param))
+			}
+		}
+	}
+
+	return siblings
+}
+
+// script checks that clide can run the command in pth.
+func (d *doctor) script(pth string, meta CommandNodeMeta) {
+	prog, _, err := Interpret(pth, meta.Interpreter)
+
+	var ie InterpretError
+
+	switch {
+	case errors.As(err, &ie):
+		d.report(pth, "%s", ie.Reason)
+	case err != nil:
+		d.report(pth, "%s", err)
+	case prog == pth && path.Shebang(pth) == nil && !isBinary(pth):
+		d.report(pth, "Script is executable but has no shebang")
+	}
+}
+
+// isBinary reports whether the file in pth looks like a compiled program,
+// which needs no shebang.
+func isBinary(pth string) bool {
+	f, err := os.Open(pth)
+
+	if err != nil {
+		return false
+	}
+
+	defer f.Close()
+
+	head, _ := bufio.NewReader(f).Peek(512)
+
+	return slices.Contains(head, 0)
+}
@@ -0,0 +1,119 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Parameter formats are declared in metadata with type.<name>=<format>.
+// Enum and regex formats carry their argument after a colon, eg:
+//
+//	# clide: type.env=enum:dev|staging|prod
+//	# clide: type.tag=regex:^v[0-9]+$
+const (
+	CommandNodeParamFormatString = "string"
+	CommandNodeParamFormatInt    = "int"
+	CommandNodeParamFormatBool   = "bool"
+	CommandNodeParamFormatPath   = "path"
+	CommandNodeParamFormatFile   = "file"
+	CommandNodeParamFormatDir    = "dir"
+	CommandNodeParamFormatEnum   = "enum"
+	CommandNodeParamFormatRegex  = "regex"
+)
+
+func (p CommandNodeParameter) format() (string, string) {
+	format, arg, _ := strings.Cut(p.Format, ":")
+
+	if format == "" {
+		return CommandNodeParamFormatString, arg
+	}
+
+	return format, arg
+}
+
+// Hint describes the values accepted by the parameter, or is empty if any
+// value is accepted.
+func (p CommandNodeParameter) Hint() string {
+	format, arg := p.format()
+
+	switch format {
+	case CommandNodeParamFormatInt:
+		return "an integer"
+	case CommandNodeParamFormatBool:
+		return "true or false"
+	case CommandNodeParamFormatPath:
+		return "an existing path"
+	case CommandNodeParamFormatFile:
+		return "an existing file"
+	case CommandNodeParamFormatDir:
+		return "an existing directory"
+	case CommandNodeParamFormatEnum:
+		return "one of " + strings.ReplaceAll(arg, "|", ", ")
+	case CommandNodeParamFormatRegex:
+		return "a match for " + arg
+	}
+
+	return ""
+}
+
+// Validate checks value against the format of the parameter.
+func (p CommandNodeParameter) Validate(value string) error {
+	format, arg := p.format()
+
+	switch format {
+	case CommandNodeParamFormatString:
+		return nil
+
+	case CommandNodeParamFormatInt:
+		if _, err := strconv.Atoi(value); err == nil {
+			return nil
+		}
+
+	case CommandNodeParamFormatBool:
+		if _, err := strconv.ParseBool(value); err == nil {
+			return nil
+		}
+
+	case CommandNodeParamFormatPath:
+		if _, err := os.Stat(value); err == nil {
+			return nil
+		}
+
+	case CommandNodeParamFormatFile:
+		if info, err := os.Stat(value); err == nil && !info.IsDir() {
+			return nil
+		}
+
+	case CommandNodeParamFormatDir:
+		if info, err := os.Stat(value); err == nil && info.IsDir() {
+			return nil
+		}
+
+	case CommandNodeParamFormatEnum:
+		for _, option := range strings.Split(arg, "|") {
+			if option == value {
+				return nil
+			}
+		}
+
+	case CommandNodeParamFormatRegex:
+		re, err := regexp.Compile(arg)
+
+		if err != nil {
+			return errors.New(fmt.Sprintf("Invalid regex for %s: %s", p.Name, err))
+		}
+
+		if re.MatchString(value) {
+			return nil
+		}
+
+	default:
+		return errors.New(fmt.Sprintf("Unknown type '%s' for %s", format, p.Name))
+	}
+
+	return errors.New(fmt.Sprintf("'%s' is not valid for %s, expected %s", value, p.Name, p.Hint()))
+}
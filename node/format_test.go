@@ -0,0 +1,56 @@
+package node
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	missing := filepath.Join(dir, "missing")
+
+	tests := []struct {
+		format string
+		value  string
+		valid  bool
+	}{
+		{"", "anything", true},
+		{"string", "", true},
+		{"int", "42", true},
+		{"int", "-7", true},
+		{"int", "4.2", false},
+		{"bool", "true", true},
+		{"bool", "0", true},
+		{"bool", "yes", false},
+		{"path", file, true},
+		{"path", dir, true},
+		{"path", missing, false},
+		{"file", file, true},
+		{"file", dir, false},
+		{"dir", dir, true},
+		{"dir", file, false},
+		{"dir", missing, false},
+		{"enum:dev|staging|prod", "staging", true},
+		{"enum:dev|staging|prod", "test", false},
+		{"enum:dev|staging|prod", "dev|staging", false},
+		{"regex:^v[0-9]+$", "v12", true},
+		{"regex:^v[0-9]+$", "12", false},
+		{"regex:[", "[", false},
+		{"float", "1.5", false},
+	}
+
+	for _, test := range tests {
+		p := CommandNodeParameter{Name: "param", Format: test.format}
+
+		if err := p.Validate(test.value); (err == nil) != test.valid {
+			t.Errorf("Validate(%q) with format %q = %v, want valid %v", test.value, test.format, err, test.valid)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package node
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/TeddyRandby/clide/path"
+)
+
+// Interpreters run scripts which are not executable, or which have no
+// shebang, keyed by file extension.
+var Interpreters = map[string][]string{
+	".sh":   {"sh"},
+	".bash": {"bash"},
+	".zsh":  {"zsh"},
+	".fish": {"fish"},
+	".py":   {"python3"},
+	".js":   {"node"},
+	".mjs":  {"node"},
+	".ts":   {"npx", "tsx"},
+	".rb":   {"ruby"},
+	".pl":   {"perl"},
+	".php":  {"php"},
+	".lua":  {"lua"},
+	".go":   {"go", "run"},
+}
+
+// InterpretError is a script which clide has no way to run, and why.
+type InterpretError struct {
+	Path   string
+	Reason string
+}
+
+func (e InterpretError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Reason)
+}
+
+// Interpret finds how to run the script at pth. It returns the program to
+// execute, and its arguments starting with argv[0].
+//
+// The interpreter given in metadata always wins. Otherwise executable files
+// run directly, unless they have no shebang and a known extension, in which
+// case the interpreter for the extension is used. Scripts which lost their
+// exec bit fall back on their shebang, and then on their extension.
+func Interpret(pth string, interpreter string) (string, []string, error) {
+	shebang := path.Shebang(pth)
+	registered, known := Interpreters[strings.ToLower(filepath.Ext(pth))]
+
+	var argv []string
+
+	switch {
+	case interpreter != "":
+		argv = strings.Fields(interpreter)
+	case path.IsExecutable(pth) && (shebang != nil || !known):
+		return pth, []string{filepath.Base(pth)}, nil
+	case shebang != nil:
+		argv = shebang
+	case known:
+		argv = registered
+	default:
+		return "", nil, InterpretError{pth, "No interpreter, make it executable, add a shebang, or give it an extension like .sh"}
+	}
+
+	prog, err := exec.LookPath(argv[0])
+
+	if err != nil {
+		return "", nil, InterpretError{pth, fmt.Sprintf("Interpreter '%s' was not found", argv[0])}
+	}
+
+	return prog, append(append([]string{}, argv...), pth), nil
+}
@@ -1,9 +1,12 @@
 package node
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"unicode"
 
@@ -16,13 +19,70 @@ const (
 	NodeTypeModule  = ">"
 )
 
+// CommandNode is a command or module in the tree. Root is the directory of
+// the tree the node was loaded from, and Source names that tree when it is
+// not the project's own. Err is why the node is broken, if it is. Problems
+// are those found while merging other trees into the node.
 type CommandNode struct {
 	Name     string
 	Shortcut string
 	Path     string
+	Root     string
+	Source   string
 	Type     string
+	Meta     CommandNodeMeta
 	Children []CommandNode
 	Parent   *CommandNode
+	Err      error
+	Problems []Problem
+}
+
+// CommandNodeSourceUser marks the nodes from the tree of the user.
+const CommandNodeSourceUser = "user"
+
+const (
+	CommandNodeMetaPrefix      = "clide:"
+	CommandNodeMetaDescription = "description"
+	CommandNodeMetaAlias       = "alias"
+	CommandNodeMetaHelp        = "help"
+	CommandNodeMetaDangerous   = "dangerous"
+	CommandNodeMetaArgs        = "args"
+	CommandNodeMetaInterpreter = "interpreter"
+	CommandNodeMetaWorkdir     = "workdir"
+	CommandNodeMetaFile        = ".meta"
+	CommandNodeMetaType        = "type."
+)
+
+// CommandNodeMeta is declared in the leading comments of a script, with lines
+// like:
+//
+//	# clide: description=Deploy the current branch
+//	# clide: alias=ship
+//	# clide: help=Builds and pushes an image, then restarts the service.
+//	# clide: type.replicas=int
+//	# clide: dangerous
+//	# clide: args=Extra flags for docker build
+//	# clide: interpreter=python3 -u
+//	# clide: workdir=root
+//
+// Aliases may be comma separated, and help may span several lines. Types
+// are keyed by parameter name, see CommandNodeParameter.Validate. Dangerous
+// commands must be confirmed before they run. Args describes the arguments
+// the command accepts after its name, if it accepts any. Interpreter runs
+// the script in place of its shebang or extension. Workdir is one of the
+// CommandNodeWorkdir constants.
+//
+// Modules, and parameter directories, declare their metadata in a .meta file
+// made up of the same lines.
+type CommandNodeMeta struct {
+	Description string
+	Aliases     []string
+	Help        string
+	Dangerous   bool
+	Args        string
+	Interpreter string
+	Workdir     string
+	Types       map[string]string
 }
 
 const (
@@ -34,6 +94,8 @@ type CommandNodeParameter struct {
 	Shortcut string
 	Name     string
 	Type     string
+	Format   string
+	Multi    bool
 	Value    []string
 }
 
@@ -48,7 +110,23 @@ func (n CommandNode) Title() string {
 	return fmt.Sprintf("%s %s", n.Type, n.Name)
 }
 
-func (n CommandNode) Description() string { return n.clideRelativePath() }
+func (n CommandNode) Description() string {
+	if n.Err != nil {
+		return n.Err.Error()
+	}
+
+	description := n.RelativePath()
+
+	if n.Meta.Description != "" {
+		description = n.Meta.Description
+	}
+
+	if n.Source != "" {
+		return fmt.Sprintf("%s (%s)", description, n.Source)
+	}
+
+	return description
+}
 
 func (n CommandNode) FilterValue() string { return n.Name }
 
@@ -82,13 +160,143 @@ func parameterNameAndShortcut(original string) (string, string) {
 	return strings.ToLower(name), strings.ToLower(shortcut)
 }
 
+func parseMetaComment(line string) (string, bool) {
+	for _, prefix := range []string{"#", "//", "--"} {
+		if strings.HasPrefix(line, prefix) {
+			return strings.TrimSpace(strings.TrimPrefix(line, prefix)), true
+		}
+	}
+
+	return "", false
+}
+
+// parseMeta reads the metadata of the script at pth from its leading block of
+// comments. It stops at the first line of code.
+func parseMeta(pth string) CommandNodeMeta {
+	var meta CommandNodeMeta
+
+	file, err := os.Open(pth)
+
+	if err != nil {
+		return meta
+	}
+
+	defer file.Close()
+
+	help := make([]string, 0)
+
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#!") {
+			continue
+		}
+
+		comment, ok := parseMetaComment(line)
+
+		if !ok {
+			break
+		}
+
+		if !strings.HasPrefix(comment, CommandNodeMetaPrefix) {
+			continue
+		}
+
+		key, value, _ := strings.Cut(strings.TrimPrefix(comment, CommandNodeMetaPrefix), "=")
+
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		if strings.HasPrefix(key, CommandNodeMetaType) {
+			if meta.Types == nil {
+				meta.Types = make(map[string]string)
+			}
+
+			meta.Types[strings.ToLower(strings.TrimPrefix(key, CommandNodeMetaType))] = value
+			continue
+		}
+
+		switch key {
+		case CommandNodeMetaDescription:
+			meta.Description = value
+		case CommandNodeMetaAlias:
+			for _, alias := range strings.Split(value, ",") {
+				if alias = strings.ToLower(strings.TrimSpace(alias)); alias != "" {
+					meta.Aliases = append(meta.Aliases, alias)
+				}
+			}
+		case CommandNodeMetaHelp:
+			help = append(help, value)
+		case CommandNodeMetaArgs:
+			meta.Args = value
+		case CommandNodeMetaInterpreter:
+			meta.Interpreter = value
+		case CommandNodeMetaWorkdir:
+			meta.Workdir = value
+		case CommandNodeMetaDangerous:
+			dangerous, err := strconv.ParseBool(value)
+			meta.Dangerous = value == "" || (err == nil && dangerous)
+		}
+	}
+
+	meta.Help = strings.Join(help, "\n")
+
+	return meta
+}
+
+// Working directories a command may run in.
+const (
+	CommandNodeWorkdirRoot       = "root"
+	CommandNodeWorkdirScript     = "script"
+	CommandNodeWorkdirInvocation = "invocation"
+)
+
+// Workdir is the directory the command should run in, or "" to run where
+// clide was invoked. A command without a workdir inherits the one declared
+// by the closest directory above it.
+func (n CommandNode) Workdir() (string, error) {
+	// The root is that of the tree the node belongs to, except for the tree
+	// of the user, whose commands run at the root of the project.
+	root := &n
+
+	for root.Parent != nil && (root.Path != root.Root || root.Source != "") {
+		root = root.Parent
+	}
+
+	workdir := n.Meta.Workdir
+
+	for dir := filepath.Dir(n.Path); workdir == "" && strings.HasPrefix(dir, n.Root); dir = filepath.Dir(dir) {
+		workdir = parseMeta(filepath.Join(dir, CommandNodeMetaFile)).Workdir
+
+		if dir == n.Root {
+			break
+		}
+	}
+
+	switch workdir {
+	case "", CommandNodeWorkdirInvocation:
+		return "", nil
+	case CommandNodeWorkdirRoot:
+		return filepath.Dir(root.Path), nil
+	case CommandNodeWorkdirScript:
+		return filepath.Dir(n.Path), nil
+	}
+
+	return "", errors.New(fmt.Sprintf("Unknown workdir '%s' for %s, expected %s, %s or %s", workdir, n.Name,
+		CommandNodeWorkdirRoot, CommandNodeWorkdirScript, CommandNodeWorkdirInvocation))
+}
+
 func (n CommandNode) Leaves() []CommandNode {
 	leaves := make([]CommandNode, 0)
 
 	for _, child := range n.Children {
 		switch child.Type {
 		case NodeTypeCommand:
-			leaves = append(leaves, child)
+			if child.Err == nil {
+				leaves = append(leaves, child)
+			}
 		case NodeTypeModule:
 			leaves = append(leaves, child.Leaves()...)
 		}
@@ -97,7 +305,9 @@ func (n CommandNode) Leaves() []CommandNode {
 	return leaves
 }
 
-func (n CommandNode) Steps() string {
+// StepNames are the names of the modules leading to the node from the root,
+// followed by its own name.
+func (n CommandNode) StepNames() []string {
 	steps := make([]string, 0)
 
 	node := &n
@@ -109,25 +319,32 @@ func (n CommandNode) Steps() string {
 
 	slices.Reverse(steps)
 
-	return strings.Join(steps, " ")
+	return steps
 }
 
-func (n CommandNode) clideRelativePath() string {
-	return filepath.Join(n.clideRelativeSteps()...)
+func (n CommandNode) Steps() string {
+	return strings.Join(n.StepNames(), " ")
 }
 
-func (n CommandNode) clideRelativeSteps() []string {
-	steps := strings.Split(n.Path, "/")
+// RelativePath is the path of the node relative to the root of its tree.
+func (n CommandNode) RelativePath() string {
+	rel, err := filepath.Rel(n.Root, n.Path)
 
-	root := slices.Index(steps, ".clide")
+	if err != nil {
+		return n.Path
+	}
+
+	return rel
+}
 
-	return steps[root+1:]
+func (n CommandNode) relativeSteps() []string {
+	return strings.Split(filepath.ToSlash(n.RelativePath()), "/")
 }
 
 func (n CommandNode) Parameters() []CommandNodeParameter {
 	params := make([]CommandNodeParameter, 0)
 
-	steps := n.clideRelativeSteps()
+	steps := n.relativeSteps()
 
 	for _, step := range steps {
 		if path.IsInputParameter(step) {
@@ -136,6 +353,7 @@ func (n CommandNode) Parameters() []CommandNodeParameter {
 				Name:     name,
 				Shortcut: shortcut,
 				Type:     CommandNodeParamTypeInput,
+				Format:   n.Meta.Types[name],
 			})
 		} else if path.IsSelectParameter(step) {
 			name, shortcut := parameterNameAndShortcut(step)
@@ -143,6 +361,8 @@ func (n CommandNode) Parameters() []CommandNodeParameter {
 				Name:     name,
 				Shortcut: shortcut,
 				Type:     CommandNodeParamTypeSelect,
+				Format:   n.Meta.Types[name],
+				Multi:    path.IsMultiParameter(step),
 			})
 		}
 	}
@@ -150,62 +370,281 @@ func (n CommandNode) Parameters() []CommandNodeParameter {
 	return params
 }
 
+// Root loads the command tree of the project, with the trees of the
+// subprojects between it and the working directory nested as modules, and the
+// tree of the user merged into it. Without a project, the tree of the user is
+// used alone.
 func Root() (*CommandNode, error) {
-	root, err := path.FindRoot()
+	trees, err := path.FindTrees()
 
 	if err != nil {
 		return nil, err
 	}
 
-	if root == "" {
-		return nil, err
+	user := path.UserRoot()
+
+	if len(trees) == 0 && user == "" {
+		return nil, nil
 	}
 
-	node, err := New(nil, root)
+	if len(trees) == 0 {
+		node, err := New(nil, user)
 
-	if err != nil {
-		return nil, err
+		if err != nil {
+			return nil, err
+		}
+
+		node.setSource(CommandNodeSourceUser)
+
+		return node, nil
+	}
+
+	var node *CommandNode
+
+	for _, tree := range trees {
+		outer, err := New(nil, tree)
+
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("In %s: %s", tree, err))
+		}
+
+		if node != nil {
+			outer.Nest(node)
+		}
+
+		node = outer
+	}
+
+	if user != "" && !slices.Contains(trees, user) {
+		tree, err := New(nil, user)
+
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("In %s: %s", user, err))
+		}
+
+		node.Mount(tree, CommandNodeSourceUser)
 	}
 
 	return node, nil
 }
 
+// Mount interleaves the children of tree with those of n, marking them with
+// source. Children of tree whose name is taken in n are left out, and
+// reported in the Problems of n.
+func (n *CommandNode) Mount(tree *CommandNode, source string) {
+	if tree.Err != nil {
+		n.Problems = append(n.Problems, Problem{tree.Path, tree.Err.Error()})
+	}
+
+	for _, child := range tree.Children {
+		if existing, err := n.findChild(child.Name); err == nil {
+			n.Problems = append(n.Problems, Problem{child.Path, fmt.Sprintf("Shadowed by %s", existing.Path)})
+			continue
+		}
+
+		child.setSource(source)
+		n.Children = append(n.Children, child)
+	}
+
+	n.reparent()
+}
+
+// Tree is the module which the tree in dir was nested as, or n itself.
+func (n *CommandNode) Tree(dir string) *CommandNode {
+	if n.Path == dir {
+		return n
+	}
+
+	for i := range n.Children {
+		if n.Children[i].Type != NodeTypeModule {
+			continue
+		}
+
+		if tree := n.Children[i].Tree(dir); tree != nil {
+			return tree
+		}
+	}
+
+	return nil
+}
+
+// Nest adds tree as a module of n, named after the directory containing it.
+// The module replaces any child of n with the same name, as the closer tree
+// takes priority.
+func (n *CommandNode) Nest(tree *CommandNode) {
+	dir := filepath.Dir(tree.Path)
+
+	tree.Name, tree.Shortcut = moduleNameAndShortcut(filepath.Base(dir))
+
+	if tree.Meta.Description == "" {
+		if rel, err := filepath.Rel(filepath.Dir(n.Root), dir); err == nil {
+			tree.Meta.Description = rel
+		}
+	}
+
+	n.Problems = append(n.Problems, tree.Problems...)
+	tree.Problems = nil
+
+	n.Children = slices.DeleteFunc(n.Children, func(child CommandNode) bool {
+		if child.Name != tree.Name {
+			return false
+		}
+
+		n.Problems = append(n.Problems, Problem{child.Path, fmt.Sprintf("Shadowed by %s", tree.Path)})
+		return true
+	})
+
+	n.Children = append(n.Children, *tree)
+
+	n.reparent()
+}
+
+func (n *CommandNode) setSource(source string) {
+	n.Source = source
+
+	for i := range n.Children {
+		n.Children[i].setSource(source)
+	}
+}
+
+// reparent points the children of n, and theirs, back at their parents in the
+// tree, after children have moved between trees.
+func (n *CommandNode) reparent() {
+	for i := range n.Children {
+		n.Children[i].Parent = n
+		n.Children[i].reparent()
+	}
+}
+
+// New loads the node in pth, along with everything under it. A node which
+// can't be loaded is kept, with the reason in its Err, so that the rest of the
+// tree stays usable.
 func New(parent *CommandNode, pth string) (*CommandNode, error) {
 	original_name := filepath.Base(pth)
 
-	if strings.HasPrefix(original_name, ".") && original_name != ".clide" {
+	if parent != nil && strings.HasPrefix(original_name, ".") {
 		return nil, nil
 	}
 
-	if path.IsParameter(pth) && path.IsLeaf(pth) {
-		return nil, errors.New("A leaf cannot require a parameter")
+	if parent != nil && filepath.Dir(pth) == parent.Root && original_name == path.ConfigFile {
+		return nil, nil
 	}
 
-	name, shortcut := moduleNameAndShortcut(original_name)
+	node := newNode(parent, pth)
 
-	node := new(CommandNode)
-	node.Parent = parent
-	node.Name = name
-	node.Shortcut = shortcut
-	node.Path = pth
+	if _, err := os.Stat(pth); err != nil {
+		if target, err := os.Readlink(pth); err == nil {
+			node.Err = errors.New(fmt.Sprintf("Broken symlink to %s", target))
+		} else {
+			node.Err = err
+		}
+
+		return node, nil
+	}
+
+	if path.IsParameter(pth) && path.IsLeaf(pth) {
+		node.Err = errors.New("A leaf cannot require a parameter")
+		return node, nil
+	}
 
 	if path.IsLeaf(pth) {
 		node.Type = NodeTypeCommand
+		node.Meta = parseMeta(pth)
 		return node, nil
 	}
 
 	node.Type = NodeTypeModule
+	node.Meta = parseMeta(filepath.Join(pth, CommandNodeMetaFile))
 
 	childs, err := children(node, pth)
 
 	if err != nil {
-		return nil, err
+		node.Err = err
 	}
 
 	node.Children = childs
 	return node, nil
 }
 
+// newNode is the command in pth, before anything is known about it.
+func newNode(parent *CommandNode, pth string) *CommandNode {
+	name, shortcut := moduleNameAndShortcut(filepath.Base(pth))
+
+	node := new(CommandNode)
+	node.Parent = parent
+	node.Name = name
+	node.Shortcut = shortcut
+	node.Path = pth
+	node.Root = pth
+	node.Type = NodeTypeCommand
+
+	if parent != nil {
+		node.Root = parent.Root
+	}
+
+	return node
+}
+
+// Matches returns the children which name selects. Children named exactly
+// name win, then those with name as their shortcut or one of their aliases,
+// and then those whose name starts with name. Broken children only match
+// when no healthy child does.
+func (n CommandNode) Matches(name string) []*CommandNode {
+	tiers := []func(child CommandNode) bool{
+		func(child CommandNode) bool { return child.Name == name },
+		func(child CommandNode) bool {
+			return child.Shortcut == name || slices.Contains(child.Meta.Aliases, name)
+		},
+		func(child CommandNode) bool { return strings.HasPrefix(child.Name, name) },
+	}
+
+	for _, matches := range tiers {
+		healthy, broken := make([]*CommandNode, 0), make([]*CommandNode, 0)
+
+		for i := range n.Children {
+			switch {
+			case !matches(n.Children[i]):
+			case n.Children[i].Err != nil:
+				broken = append(broken, &n.Children[i])
+			default:
+				healthy = append(healthy, &n.Children[i])
+			}
+		}
+
+		if len(healthy) > 0 {
+			return healthy
+		}
+
+		if len(broken) > 0 {
+			return broken
+		}
+	}
+
+	return nil
+}
+
+// Resolve returns the children which name selects from n, like Matches.
+// Nested trees fall back to the trees around them, for names they lack.
+func (n *CommandNode) Resolve(name string) []*CommandNode {
+	matches := n.Matches(name)
+
+	for t := n; len(matches) == 0 && t.Parent != nil && t.Path == t.Root; t = t.Parent {
+		matches = t.Parent.Matches(name)
+	}
+
+	return matches
+}
+
+// Match returns the child which name selects, or nil if it selects none or
+// is ambiguous.
+func (n CommandNode) Match(name string) *CommandNode {
+	if matches := n.Matches(name); len(matches) == 1 {
+		return matches[0]
+	}
+
+	return nil
+}
+
 func (n CommandNode) findChild(name string) (*CommandNode, error) {
 	for _, child := range n.Children {
 		if child.Name == name {
@@ -224,17 +663,19 @@ func children(parent *CommandNode, dir string) ([]CommandNode, error) {
 
 	var nodes []CommandNode
 	for _, child := range childs {
-		if path.IsParameter(child) {
+		if path.IsParameter(child) && path.IsModule(child) {
 			grandchilds, err := children(parent, child)
 
 			if err != nil {
-				return nil, err
+				node := newNode(parent, child)
+				node.Err = err
+				nodes = append(nodes, *node)
 			}
 
 			nodes = append(nodes, grandchilds...)
 		} else {
 			if path.IsLeaf(child) {
-				if strings.Contains(filepath.Base(child), ".") {
+				if strings.Contains(filepath.Base(child), ".") || path.IsParameter(child) {
 					node, err := New(parent, child)
 
 					if err != nil {
@@ -263,11 +704,11 @@ func children(parent *CommandNode, dir string) ([]CommandNode, error) {
 	}
 
 	for i, a := range nodes {
-		for j, b := range nodes {
-			if i != j {
-				if a.Name == b.Name {
-					return nil, errors.New(fmt.Sprintf("Duplicate leaf %s", a.Name))
-				}
+		for _, b := range nodes[:i] {
+			if a.Name == b.Name && b.Err == nil {
+				nodes[i].Err = errors.New(fmt.Sprintf("Duplicate name %s, also used by %s", a.Name, b.Path))
+				nodes[i].Children = nil
+				break
 			}
 		}
 	}
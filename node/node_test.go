@@ -0,0 +1,155 @@
+package node
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMatches(t *testing.T) {
+	broken := errors.New("broken")
+
+	tests := []struct {
+		name     string
+		children []CommandNode
+		want     []string
+	}{
+		{
+			name:     "build",
+			children: []CommandNode{{Name: "build"}, {Name: "builder"}},
+			want:     []string{"build"},
+		},
+		{
+			name:     "b",
+			children: []CommandNode{{Name: "build", Shortcut: "b"}, {Name: "b-side"}},
+			want:     []string{"build"},
+		},
+		{
+			name:     "b",
+			children: []CommandNode{{Name: "b"}, {Name: "build", Shortcut: "b"}},
+			want:     []string{"b"},
+		},
+		{
+			name:     "ship",
+			children: []CommandNode{{Name: "deploy", Meta: CommandNodeMeta{Aliases: []string{"ship"}}}, {Name: "shipit"}},
+			want:     []string{"deploy"},
+		},
+		{
+			name:     "bui",
+			children: []CommandNode{{Name: "build"}, {Name: "bump"}, {Name: "builder"}},
+			want:     []string{"build", "builder"},
+		},
+		{
+			name:     "build",
+			children: []CommandNode{{Name: "build", Err: broken}, {Name: "deploy", Shortcut: "build"}},
+			want:     []string{"build"},
+		},
+		{
+			name:     "bu",
+			children: []CommandNode{{Name: "build", Err: broken}, {Name: "bump"}},
+			want:     []string{"bump"},
+		},
+		{
+			name:     "t",
+			children: []CommandNode{{Name: "test", Shortcut: "t", Err: broken}, {Name: "tidy"}},
+			want:     []string{"test"},
+		},
+		{
+			name:     "x",
+			children: []CommandNode{{Name: "build"}},
+			want:     nil,
+		},
+	}
+
+	for _, test := range tests {
+		n := CommandNode{Children: test.children}
+
+		var got []string
+
+		for _, match := range n.Matches(test.name) {
+			got = append(got, match.Name)
+		}
+
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Matches(%q) in %v = %v, want %v", test.name, test.children, got, test.want)
+		}
+	}
+}
+
+func TestMatchesPointsIntoChildren(t *testing.T) {
+	n := CommandNode{Children: []CommandNode{{Name: "build"}}}
+
+	if match := n.Match("build"); match != &n.Children[0] {
+		t.Errorf("Match(%q) = %p, want %p", "build", match, &n.Children[0])
+	}
+
+	if match := n.Match("deploy"); match != nil {
+		t.Errorf("Match(%q) = %v, want nil", "deploy", match)
+	}
+}
+
+func TestParseMeta(t *testing.T) {
+	tests := []struct {
+		script string
+		want   CommandNodeMeta
+	}{
+		{
+			script: "#!/bin/sh\n# clide: description = Deploy the app\n# clide: alias = Ship, push,\necho hi\n",
+			want:   CommandNodeMeta{Description: "Deploy the app", Aliases: []string{"ship", "push"}},
+		},
+		{
+			script: "// clide: help=First line\n// clide: help=Second line\n",
+			want:   CommandNodeMeta{Help: "First line\nSecond line"},
+		},
+		{
+			script: "-- clide: description=Lua\n",
+			want:   CommandNodeMeta{Description: "Lua"},
+		},
+		{
+			script: "#!/bin/sh\n\n# An ordinary comment\n# clide: description=Kept\nset -e\n# clide: description=Ignored\n",
+			want:   CommandNodeMeta{Description: "Kept"},
+		},
+		{
+			script: "# clide: type.Env=enum:dev|prod\n# clide: type.count=int\n",
+			want:   CommandNodeMeta{Types: map[string]string{"env": "enum:dev|prod", "count": "int"}},
+		},
+		{
+			script: "# clide: dangerous\n",
+			want:   CommandNodeMeta{Dangerous: true},
+		},
+		{
+			script: "# clide: dangerous=false\n",
+			want:   CommandNodeMeta{},
+		},
+		{
+			script: "# clide: args = <file>...\n",
+			want:   CommandNodeMeta{Args: "<file>..."},
+		},
+		{
+			script: "-- clide: interpreter=lua5.4\n",
+			want:   CommandNodeMeta{Interpreter: "lua5.4"},
+		},
+		{
+			script: "# clide: workdir=root\n",
+			want:   CommandNodeMeta{Workdir: "root"},
+		},
+	}
+
+	for _, test := range tests {
+		pth := filepath.Join(t.TempDir(), "script")
+
+		if err := os.WriteFile(pth, []byte(test.script), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := parseMeta(pth); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("parseMeta(%q) = %+v, want %+v", test.script, got, test.want)
+		}
+	}
+
+	if got := parseMeta(filepath.Join(t.TempDir(), "missing")); !reflect.DeepEqual(got, CommandNodeMeta{}) {
+		t.Errorf("parseMeta of a missing file = %+v, want none", got)
+	}
+}
@@ -1,6 +1,8 @@
 package path
 
 import (
+	"bufio"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -11,10 +13,15 @@ const (
 	ParamInputSuffix  = "]"
 	ParamSelectPrefix = "{"
 	ParamSelectSuffix = "}"
+	ParamMultiSuffix  = "+"
 	ParamBracketChars = ParamInputPrefix + ParamInputSuffix + ParamSelectPrefix + ParamSelectSuffix
-	ParamChars        = ParamBracketChars
+	ParamChars        = ParamBracketChars + ParamMultiSuffix
 )
 
+// RootMarkers are the files and directories which mark the root of a
+// repository, for the version control systems clide knows about.
+var RootMarkers = []string{".git", ".hg", ".jj", ".svn"}
+
 func Exists(filename string) bool {
 	_, err := os.Stat(filename)
 
@@ -47,6 +54,47 @@ func Children(path string) ([]string, error) {
 	return children, nil
 }
 
+// IsExecutable reports whether path is a file which may be executed.
+func IsExecutable(path string) bool {
+	info, err := os.Stat(path)
+
+	if err != nil {
+		return false
+	}
+
+	return !info.IsDir() && info.Mode().Perm()&0o111 != 0
+}
+
+// Shebang returns the interpreter and arguments named on the first line of
+// the script at path, or nil if it has none.
+func Shebang(path string) []string {
+	file, err := os.Open(path)
+
+	if err != nil {
+		return nil
+	}
+
+	defer file.Close()
+
+	line, err := bufio.NewReader(file).ReadString('\n')
+
+	if err != nil && line == "" {
+		return nil
+	}
+
+	if !strings.HasPrefix(line, "#!") {
+		return nil
+	}
+
+	fields := strings.Fields(strings.TrimPrefix(line, "#!"))
+
+	if len(fields) == 0 {
+		return nil
+	}
+
+	return fields
+}
+
 func Parent(path string) string {
 	return filepath.Join(path, "..")
 }
@@ -71,6 +119,14 @@ func IsSelectParameter(path string) bool {
 	return hasPrefixAndSuffix(p, ParamSelectPrefix, ParamSelectSuffix)
 }
 
+// IsMultiParameter reports whether path is a select parameter which accepts
+// several values, like {People+}.
+func IsMultiParameter(path string) bool {
+	p := filepath.Base(path)
+
+	return IsSelectParameter(p) && strings.HasSuffix(strings.TrimSuffix(p, ParamSelectSuffix), ParamMultiSuffix)
+}
+
 func IsInputParameter(path string) bool {
 	p := filepath.Base(path)
 
@@ -89,40 +145,168 @@ func IsRoot(path string) bool {
 	return Exists(filepath.Join(path, ".clide"))
 }
 
-func findRoot(path string) (string, error) {
-	if Exists(filepath.Join(path, ".git")) {
+// ConfigFile configures clide, either at the root of a command tree or in
+// the ConfigDir of the user.
+const ConfigFile = "clide.toml"
+
+// ConfigDir is where the user keeps their configuration for clide, following
+// the XDG base directory spec.
+func ConfigDir() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "clide"), nil
+	}
+
+	home, err := os.UserHomeDir()
+
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".config", "clide"), nil
+}
+
+// UserCommandsDir is the directory in the ConfigDir which holds the personal
+// command tree of the user, apart from their config.
+const UserCommandsDir = "commands"
+
+// UserRoot is the directory holding the personal command tree of the user,
+// which is CLIDE_HOME, or else the UserCommandsDir in the ConfigDir. It is ""
+// if there is none.
+func UserRoot() string {
+	dir := os.Getenv("CLIDE_HOME")
+
+	if dir == "" {
+		config, err := ConfigDir()
+
+		if err != nil {
+			return ""
+		}
+
+		dir = filepath.Join(config, UserCommandsDir)
+	}
+
+	info, err := os.Stat(dir)
+
+	if err != nil || !info.IsDir() {
+		return ""
+	}
+
+	return dir
+}
+
+// StateDir is where clide keeps state between runs, following the XDG base
+// directory spec.
+func StateDir() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "clide"), nil
+	}
+
+	home, err := os.UserHomeDir()
+
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".local", "state", "clide"), nil
+}
+
+func isRepository(path string) bool {
+	for _, marker := range RootMarkers {
+		if Exists(filepath.Join(path, marker)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// findTrees lists the .clide directories from path up to the root of its
+// repository, closest first. Outside of a repository, the nearest .clide
+// directory is the project's root.
+func findTrees(path string) ([]string, error) {
+	trees := make([]string, 0)
+
+	for {
 		if Exists(filepath.Join(path, ".clide")) {
-			return filepath.Join(path, ".clide"), nil
+			trees = append(trees, filepath.Join(path, ".clide"))
 		}
 
-		return "", nil
+		if isRepository(path) {
+			return trees, nil
+		}
+
+		parentPath := filepath.Join(path, "..")
+
+		if parentPath == path {
+			if len(trees) > 1 {
+				trees = trees[:1]
+			}
+
+			return trees, nil
+		}
+
+		path = parentPath
 	}
+}
 
-	parentPath := filepath.Join(path, "..")
+// findTreesIn is findTrees for the project in root, given by CLIDE_ROOT. Root
+// is either the directory containing .clide, or a tree itself.
+func findTreesIn(path string, root string) ([]string, error) {
+	root, err := filepath.Abs(root)
 
-	if parentPath == path {
-		return "", nil
+	if err != nil {
+		return nil, err
+	}
+
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("Project root %s is not a directory", root)
+	}
+
+	tree := root
+
+	if Exists(filepath.Join(root, ".clide")) {
+		tree = filepath.Join(root, ".clide")
 	}
 
-	return findRoot(parentPath)
+	project := filepath.Dir(tree)
+	trees := make([]string, 0)
+
+	for ; path != project && strings.HasPrefix(path, project+string(filepath.Separator)); path = filepath.Dir(path) {
+		if Exists(filepath.Join(path, ".clide")) {
+			trees = append(trees, filepath.Join(path, ".clide"))
+		}
+	}
+
+	return append(trees, tree), nil
 }
 
-func FindRoot() (string, error) {
+// FindTrees lists the .clide directories from the working directory up to
+// the root of the project, closest first. The last is the project's root.
+// CLIDE_ROOT overrides where the project is.
+func FindTrees() ([]string, error) {
 	path, err := os.Getwd()
 
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	root, err := findRoot(path)
+	if root := os.Getenv("CLIDE_ROOT"); root != "" {
+		return findTreesIn(path, root)
+	}
+
+	return findTrees(path)
+}
+
+func FindRoot() (string, error) {
+	trees, err := FindTrees()
 
 	if err != nil {
 		return "", err
 	}
 
-	if root == "" {
+	if len(trees) == 0 {
 		return "", nil
 	}
 
-	return root, nil
+	return trees[len(trees)-1], nil
 }
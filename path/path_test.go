@@ -0,0 +1,155 @@
+package path
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// mkdirs creates each of dirs in root.
+func mkdirs(t *testing.T, root string, dirs ...string) {
+	t.Helper()
+
+	for _, dir := range dirs {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestFindTrees(t *testing.T) {
+	tests := []struct {
+		dirs []string
+		cwd  string
+		want []string
+	}{
+		{
+			dirs: []string{".git", ".clide", "src/deep"},
+			cwd:  "src/deep",
+			want: []string{".clide"},
+		},
+		{
+			dirs: []string{".git", ".clide", "services/.clide", "services/api/.clide", "services/api/cmd"},
+			cwd:  "services/api/cmd",
+			want: []string{"services/api/.clide", "services/.clide", ".clide"},
+		},
+		{
+			dirs: []string{".git", ".clide", "services/.clide"},
+			cwd:  ".",
+			want: []string{".clide"},
+		},
+		{
+			dirs: []string{".clide", "vendor/.git", "vendor/lib"},
+			cwd:  "vendor/lib",
+			want: []string{},
+		},
+		{
+			dirs: []string{".jj", ".clide", "services/.clide", "services/api"},
+			cwd:  "services/api",
+			want: []string{"services/.clide", ".clide"},
+		},
+		{
+			dirs: []string{".clide", "services/.clide", "services/api"},
+			cwd:  "services/api",
+			want: []string{"services/.clide"},
+		},
+		{
+			dirs: []string{".hg", "src"},
+			cwd:  "src",
+			want: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		root := t.TempDir()
+
+		mkdirs(t, root, test.dirs...)
+
+		want := make([]string, 0)
+
+		for _, tree := range test.want {
+			want = append(want, filepath.Join(root, tree))
+		}
+
+		got, err := findTrees(filepath.Join(root, test.cwd))
+
+		if err != nil {
+			t.Errorf("findTrees(%s) in %v: %s", test.cwd, test.dirs, err)
+		} else if !reflect.DeepEqual(got, want) {
+			t.Errorf("findTrees(%s) in %v = %v, want %v", test.cwd, test.dirs, got, want)
+		}
+	}
+}
+
+func TestFindTreesIn(t *testing.T) {
+	tests := []struct {
+		dirs []string
+		cwd  string
+		root string
+		want []string
+	}{
+		{
+			dirs: []string{"proj/.clide", "proj/services/.clide", "proj/services/api"},
+			cwd:  "proj/services/api",
+			root: "proj",
+			want: []string{"proj/services/.clide", "proj/.clide"},
+		},
+		{
+			dirs: []string{"proj/.clide", "proj/services/.clide"},
+			cwd:  "proj/services",
+			root: "proj/.clide",
+			want: []string{"proj/services/.clide", "proj/.clide"},
+		},
+		{
+			dirs: []string{"proj/.clide", "elsewhere/.clide"},
+			cwd:  "elsewhere",
+			root: "proj",
+			want: []string{"proj/.clide"},
+		},
+		{
+			dirs: []string{"proj/.clide", "project/.clide"},
+			cwd:  "project",
+			root: "proj",
+			want: []string{"proj/.clide"},
+		},
+		{
+			dirs: []string{"tools/deploy"},
+			cwd:  ".",
+			root: "tools",
+			want: []string{"tools"},
+		},
+	}
+
+	for _, test := range tests {
+		root := t.TempDir()
+
+		mkdirs(t, root, test.dirs...)
+
+		want := make([]string, 0)
+
+		for _, tree := range test.want {
+			want = append(want, filepath.Join(root, tree))
+		}
+
+		got, err := findTreesIn(filepath.Join(root, test.cwd), filepath.Join(root, test.root))
+
+		if err != nil {
+			t.Errorf("findTreesIn(%s, %s) in %v: %s", test.cwd, test.root, test.dirs, err)
+		} else if !reflect.DeepEqual(got, want) {
+			t.Errorf("findTreesIn(%s, %s) in %v = %v, want %v", test.cwd, test.root, test.dirs, got, want)
+		}
+	}
+
+	root := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(root, "file"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, bad := range []string{filepath.Join(root, "missing"), filepath.Join(root, "file")} {
+		if _, err := findTreesIn(root, bad); err == nil {
+			t.Errorf("findTreesIn(%s, %s) succeeded, want an error", root, bad)
+		}
+	}
+}